@@ -0,0 +1,105 @@
+package halgo
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// BackoffStrategy decides how long to wait before retrying a request.
+// Attempts are numbered from 1, which is the first retry.
+type BackoffStrategy interface {
+	NextDelay(attempt int) time.Duration
+}
+
+// ConstantBackoff waits the same amount of time before every attempt.
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+func (b ConstantBackoff) NextDelay(attempt int) time.Duration {
+	return b.Delay
+}
+
+// ExponentialBackoff doubles the delay with every attempt, starting at
+// Base. If Max is set the delay will never exceed it, and without a Max
+// it stops growing at the longest time.Duration rather than overflowing.
+type ExponentialBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+func (b ExponentialBackoff) NextDelay(attempt int) time.Duration {
+	delay := b.Base
+	for i := 1; i < attempt && delay > 0; i++ {
+		if delay > math.MaxInt64/2 {
+			delay = math.MaxInt64
+			break
+		}
+
+		delay *= 2
+		if b.Max > 0 && delay >= b.Max {
+			return b.Max
+		}
+	}
+
+	if b.Max > 0 && delay > b.Max {
+		return b.Max
+	}
+
+	return delay
+}
+
+// DecorrelatedJitterBackoff picks a random delay between Base and three
+// times the previous delay, capped at Max if it's set. Spreading retries
+// out like this stops many clients retrying in lockstep.
+//
+// It remembers the previous delay, so a DecorrelatedJitterBackoff should
+// be used by pointer and not shared between unrelated requests. The
+// first attempt starts the sequence again.
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+
+	// Rand is the source of randomness. The math/rand default source is
+	// used if it's nil.
+	Rand *rand.Rand
+
+	mu   sync.Mutex
+	prev time.Duration
+}
+
+func (b *DecorrelatedJitterBackoff) NextDelay(attempt int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if attempt <= 1 || b.prev < b.Base {
+		b.prev = b.Base
+	}
+
+	upper := time.Duration(math.MaxInt64)
+	if b.prev <= math.MaxInt64/3 {
+		upper = b.prev * 3
+	}
+
+	delay := b.Base
+	if upper > b.Base {
+		delay += time.Duration(b.int63n(int64(upper - b.Base)))
+	}
+
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+
+	b.prev = delay
+	return delay
+}
+
+func (b *DecorrelatedJitterBackoff) int63n(n int64) int64 {
+	if b.Rand != nil {
+		return b.Rand.Int63n(n)
+	}
+
+	return rand.Int63n(n)
+}
@@ -0,0 +1,88 @@
+package halgo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestConstantBackoff(t *testing.T) {
+	b := ConstantBackoff{Delay: time.Second}
+
+	for attempt := 1; attempt <= 5; attempt++ {
+		if d := b.NextDelay(attempt); d != time.Second {
+			t.Errorf("Attempt %d: expected delay of 1s, got %v", attempt, d)
+		}
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff{Base: 100 * time.Millisecond, Max: time.Second}
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+
+	for i, e := range expected {
+		if d := b.NextDelay(i + 1); d != e {
+			t.Errorf("Attempt %d: expected delay of %v, got %v", i+1, e, d)
+		}
+	}
+}
+
+func TestExponentialBackoffDoesNotOverflow(t *testing.T) {
+	b := ExponentialBackoff{Base: time.Second}
+
+	for _, attempt := range []int{35, 64, 100, 1000} {
+		if d := b.NextDelay(attempt); d != math.MaxInt64 {
+			t.Errorf("Attempt %d: expected the longest delay, got %v", attempt, d)
+		}
+	}
+
+	if d := (ExponentialBackoff{Base: time.Second, Max: time.Hour}).NextDelay(1000); d != time.Hour {
+		t.Errorf("Expected the delay to be capped at Max, got %v", d)
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	b := &DecorrelatedJitterBackoff{
+		Base: 100 * time.Millisecond,
+		Max:  2 * time.Second,
+		Rand: rand.New(rand.NewSource(1)),
+	}
+
+	prev := b.Base
+	for attempt := 1; attempt <= 10; attempt++ {
+		d := b.NextDelay(attempt)
+
+		upper := prev * 3
+		if upper > b.Max {
+			upper = b.Max
+		}
+
+		if d < b.Base || d > upper {
+			t.Errorf("Attempt %d: expected delay between %v and %v, got %v", attempt, b.Base, upper, d)
+		}
+
+		prev = d
+	}
+
+	if d := b.NextDelay(1); d < b.Base || d > 3*b.Base {
+		t.Errorf("Expected first attempt to restart the sequence, got %v", d)
+	}
+}
+
+func TestDecorrelatedJitterBackoffDoesNotOverflow(t *testing.T) {
+	b := &DecorrelatedJitterBackoff{Base: time.Hour, Rand: rand.New(rand.NewSource(1))}
+
+	for attempt := 1; attempt <= 100; attempt++ {
+		if d := b.NextDelay(attempt); d < b.Base {
+			t.Fatalf("Attempt %d: expected a delay of at least %v, got %v", attempt, b.Base, d)
+		}
+	}
+}
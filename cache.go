@@ -0,0 +1,272 @@
+package halgo
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachingHttpClient is an HttpClient which wraps an existing HttpClient
+// and caches the responses of GET requests. Cached responses which have
+// an ETag or Last-Modified header are revalidated with a conditional
+// request, and the cached body is returned if the server responds with
+// 304 Not Modified. Responses with a Cache-Control max-age are served
+// straight from the cache until they expire, and responses marked
+// no-store are never cached. Responses are cached by URL and by the
+// request's Accept and credentials headers, and only served to requests
+// which match them on any headers named in the response's Vary header.
+//
+// Navigating an API requests the same link documents over and over, so
+// caching can save a lot of traffic.
+//
+//     nav := Navigator("http://api.example.com").
+//       WithClient(&halgo.CachingHttpClient{HttpClient: http.DefaultClient})
+//
+// A CachingHttpClient must be used by pointer, and is safe to use from
+// multiple goroutines.
+type CachingHttpClient struct {
+	HttpClient
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry is a cached response. Entries are never modified once they're
+// cached, so they can be read without holding the lock; a revalidated
+// entry is replaced with a new one.
+type cacheEntry struct {
+	status       string
+	statusCode   int
+	header       http.Header
+	body         []byte
+	etag         string
+	lastModified string
+	expires      time.Time
+
+	// vary are the values the request had for the headers named in the
+	// response's Vary header, which a request must match to be served it.
+	vary http.Header
+}
+
+func (c *CachingHttpClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		return c.HttpClient.Do(req)
+	}
+
+	key := cacheKey(req)
+	entry := c.get(key)
+	if entry != nil && !entry.matches(req) {
+		entry = nil
+	}
+
+	// a caller's own conditional request is passed on untouched, so a 304
+	// is for its validators rather than the cache's
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		entry = nil
+	}
+
+	if entry != nil && time.Now().Before(entry.expires) {
+		return entry.response(req), nil
+	}
+
+	if entry != nil && (entry.etag != "" || entry.lastModified != "") {
+		req = req.Clone(req.Context())
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	res, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	noStore, maxAge := parseCacheControl(res.Header.Get("Cache-Control"))
+
+	if res.StatusCode == http.StatusNotModified && entry != nil {
+		res.Body.Close()
+
+		if noStore {
+			c.set(key, nil)
+		} else if maxAge > 0 {
+			renewed := *entry
+			renewed.expires = time.Now().Add(maxAge)
+			entry = &renewed
+			c.set(key, entry)
+		}
+
+		return entry.response(req), nil
+	}
+
+	if res.StatusCode != http.StatusOK || noStore {
+		c.set(key, nil)
+		return res, nil
+	}
+
+	etag := res.Header.Get("ETag")
+	lastModified := res.Header.Get("Last-Modified")
+	vary, cacheable := varyHeader(res, req)
+	if !cacheable || etag == "" && lastModified == "" && maxAge == 0 {
+		c.set(key, nil)
+		return res, nil
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	entry = &cacheEntry{
+		status:       res.Status,
+		statusCode:   res.StatusCode,
+		header:       cloneHeader(res.Header),
+		body:         body,
+		etag:         etag,
+		lastModified: lastModified,
+		expires:      time.Now().Add(maxAge),
+		vary:         vary,
+	}
+	c.set(key, entry)
+
+	return entry.response(req), nil
+}
+
+// cacheKeyHeaders are the request headers responses are cached by, along
+// with the URL, whether or not the server names them in a Vary header. A
+// client shared between credentials, or asking for other media types,
+// never gets a response cached for another.
+var cacheKeyHeaders = []string{"Accept", "Authorization", "Cookie", "Proxy-Authorization"}
+
+// cacheKey returns the key the response to req is cached under.
+func cacheKey(req *http.Request) string {
+	var key strings.Builder
+	key.WriteString(req.URL.String())
+
+	for _, name := range cacheKeyHeaders {
+		if values, ok := req.Header[name]; ok {
+			fmt.Fprintf(&key, "\n%s: %q", name, values)
+		}
+	}
+
+	return key.String()
+}
+
+// varyHeader returns the values req has for the headers named in the Vary
+// header of res, or false if the response can't be cached because it
+// varies on everything.
+func varyHeader(res *http.Response, req *http.Request) (http.Header, bool) {
+	var vary http.Header
+
+	for _, header := range res.Header["Vary"] {
+		for _, name := range strings.Split(header, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			switch name {
+			case "":
+				continue
+			case "*":
+				return nil, false
+			}
+
+			if vary == nil {
+				vary = http.Header{}
+			}
+			vary[name] = append([]string{}, req.Header[name]...)
+		}
+	}
+
+	return vary, true
+}
+
+// matches returns whether req has the same values as the cached request
+// for every header the response varies on.
+func (e *cacheEntry) matches(req *http.Request) bool {
+	for name, values := range e.vary {
+		if fmt.Sprint(req.Header[name]) != fmt.Sprint(values) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *CachingHttpClient) get(key string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries[key]
+}
+
+// set stores an entry in the cache, or removes it if entry is nil.
+func (c *CachingHttpClient) set(key string, entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry == nil {
+		delete(c.entries, key)
+		return
+	}
+
+	if c.entries == nil {
+		c.entries = make(map[string]*cacheEntry)
+	}
+	c.entries[key] = entry
+}
+
+// response builds a new response for req from the cached entry.
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        e.status,
+		StatusCode:    e.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        cloneHeader(e.header),
+		Body:          ioutil.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// parseCacheControl returns whether a Cache-Control header forbids
+// storing the response, and how long the response is fresh for.
+func parseCacheControl(header string) (noStore bool, maxAge time.Duration) {
+	noCache := false
+
+	for _, directive := range strings.Split(header, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+
+		switch {
+		case directive == "no-store":
+			noStore = true
+		case directive == "no-cache":
+			noCache = true
+		case strings.HasPrefix(directive, "max-age="):
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && seconds > 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	if noCache {
+		maxAge = 0
+	}
+
+	return
+}
+
+func cloneHeader(h http.Header) http.Header {
+	other := make(http.Header, len(h))
+	for k, v := range h {
+		other[k] = append([]string{}, v...)
+	}
+	return other
+}
@@ -0,0 +1,204 @@
+package halgo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func createCachingTestHttpServer(cacheControl string) (*httptest.Server, map[string]int) {
+	hits := make(map[string]int)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits["total"] += 1
+
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			hits["304"] += 1
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{ "_links": { "self": { "href": "/" } } }`)
+	}))
+
+	return ts, hits
+}
+
+func getBodyWith(t *testing.T, client HttpClient, url string) string {
+	req, _ := http.NewRequest("GET", url, nil)
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Expected OK, got %d", res.StatusCode)
+	}
+
+	body, _ := ioutil.ReadAll(res.Body)
+	return string(body)
+}
+
+func TestCachingHttpClientRevalidatesWithETag(t *testing.T) {
+	ts, hits := createCachingTestHttpServer("")
+	defer ts.Close()
+
+	client := &CachingHttpClient{HttpClient: http.DefaultClient}
+
+	first := getBodyWith(t, client, ts.URL)
+	second := getBodyWith(t, client, ts.URL)
+
+	if first != second {
+		t.Errorf("Expected cached body %s, got %s", first, second)
+	}
+
+	if hits["total"] != 2 || hits["304"] != 1 {
+		t.Errorf("Expected 2 requests with 1 revalidated, got %d and %d", hits["total"], hits["304"])
+	}
+}
+
+func TestCachingHttpClientHonoursMaxAge(t *testing.T) {
+	ts, hits := createCachingTestHttpServer("max-age=60")
+	defer ts.Close()
+
+	client := &CachingHttpClient{HttpClient: http.DefaultClient}
+	nav := Navigator(ts.URL)
+	nav.HttpClient = client
+	if _, err := nav.Follow("self").Follow("self").Follow("self").Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	// one request for the root and one for its self link, the rest are
+	// served from the cache
+	if hits["total"] != 2 {
+		t.Errorf("Expected 2 requests, got %d", hits["total"])
+	}
+}
+
+func TestCachingHttpClientHonoursNoStore(t *testing.T) {
+	ts, hits := createCachingTestHttpServer("no-store")
+	defer ts.Close()
+
+	client := &CachingHttpClient{HttpClient: http.DefaultClient}
+
+	getBodyWith(t, client, ts.URL)
+	getBodyWith(t, client, ts.URL)
+
+	if hits["total"] != 2 || hits["304"] != 0 {
+		t.Errorf("Expected 2 unconditional requests, got %d with %d revalidated", hits["total"], hits["304"])
+	}
+}
+
+func TestCachingHttpClientRevalidatesConcurrently(t *testing.T) {
+	inner := &MockHttpClient{}
+	inner.RespondFunc("http://api.example.com/", func(req *http.Request) (*http.Response, error) {
+		header := http.Header{"Etag": {`"v1"`}, "Cache-Control": {"max-age=1"}}
+		time.Sleep(time.Millisecond)
+		status := http.StatusOK
+		if req.Header.Get("If-None-Match") != "" {
+			status = http.StatusNotModified
+		}
+		return &http.Response{StatusCode: status, Header: header, Body: ioutil.NopCloser(strings.NewReader("{}")), Request: req}, nil
+	})
+
+	client := &CachingHttpClient{HttpClient: inner}
+
+	// an expired entry, which every request revalidates at once
+	client.set("http://api.example.com/", &cacheEntry{statusCode: http.StatusOK, body: []byte("{}"), etag: `"v1"`})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "http://api.example.com/", nil)
+			if res, err := client.Do(req); err == nil {
+				res.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCachingHttpClientIsPerCredentialsAndVary(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		fmt.Fprint(w, r.Header.Get("Authorization")+" "+r.Header.Get("Accept-Language"))
+	}))
+	defer ts.Close()
+
+	client := &CachingHttpClient{HttpClient: http.DefaultClient}
+
+	get := func(header http.Header) string {
+		req, _ := http.NewRequest("GET", ts.URL, nil)
+		req.Header = header
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		body, _ := ioutil.ReadAll(res.Body)
+		return string(body)
+	}
+
+	requests := []http.Header{
+		{"Authorization": {"alice"}, "Accept-Language": {"en"}},
+		{"Authorization": {"bob"}, "Accept-Language": {"en"}},
+		{"Authorization": {"alice"}, "Accept-Language": {"de"}},
+		{"Authorization": {"alice"}, "Accept-Language": {"de"}},
+	}
+
+	bodies := []string{}
+	for _, header := range requests {
+		bodies = append(bodies, get(header))
+	}
+
+	expected := []string{"alice en", "bob en", "alice de", "alice de"}
+	if fmt.Sprint(bodies) != fmt.Sprint(expected) {
+		t.Errorf("Expected %q, got %q", expected, bodies)
+	}
+}
+
+func TestCachingHttpClientKeepsCallersConditions(t *testing.T) {
+	ts, hits := createCachingTestHttpServer("")
+	defer ts.Close()
+
+	client := &CachingHttpClient{HttpClient: http.DefaultClient}
+	getBodyWith(t, client, ts.URL)
+
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	req.Header.Set("If-None-Match", `"v0"`)
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK || hits["304"] != 0 {
+		t.Errorf("Expected the caller's If-None-Match to be sent, got %d with %d revalidated", res.StatusCode, hits["304"])
+	}
+
+	req.Header.Set("If-None-Match", `"v1"`)
+	res, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusNotModified {
+		t.Errorf("Expected the caller to get the 304 for its own condition, got %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,71 @@
+package halgo
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// digestAlgorithms are the RFC 3230 Digest algorithms which can be
+// verified. Digests using any other algorithm are ignored.
+var digestAlgorithms = map[string]func() hash.Hash{
+	"md5":     md5.New,
+	"sha":     sha1.New,
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// verifyBody checks the body of a response against its Content-Length,
+// Content-MD5 and Digest headers, returning a DigestMismatchError for the
+// first which doesn't match. Responses the HttpClient has decompressed
+// aren't checked, as their headers describe the compressed body.
+func verifyBody(res *http.Response, uri string, body []byte) error {
+	if res.Uncompressed {
+		return nil
+	}
+
+	url := effectiveURL(res, uri)
+
+	if res.ContentLength >= 0 && res.ContentLength != int64(len(body)) {
+		return DigestMismatchError{url, "Content-Length"}
+	}
+
+	if sum := res.Header.Get("Content-MD5"); sum != "" && !digestMatches("md5", sum, body) {
+		return DigestMismatchError{url, "Content-MD5"}
+	}
+
+	for _, value := range res.Header["Digest"] {
+		for _, digest := range strings.Split(value, ",") {
+			parts := strings.SplitN(strings.TrimSpace(digest), "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+
+			algorithm := strings.ToLower(parts[0])
+			if _, ok := digestAlgorithms[algorithm]; ok && !digestMatches(algorithm, parts[1], body) {
+				return DigestMismatchError{url, "Digest " + parts[0]}
+			}
+		}
+	}
+
+	return nil
+}
+
+// digestMatches returns whether encoded is the base64 encoded digest of
+// body with algorithm.
+func digestMatches(algorithm, encoded string, body []byte) bool {
+	expected, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+
+	h := digestAlgorithms[algorithm]()
+	h.Write(body)
+	return bytes.Equal(h.Sum(nil), expected)
+}
@@ -0,0 +1,55 @@
+package halgo
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyDigest(t *testing.T) {
+	body := `{ "_links": { "self": { "href": "/" } }, "name": "Halgo" }`
+	sum := sha256.Sum256([]byte(body))
+	good := base64.StdEncoding.EncodeToString(sum[:])
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/good":
+			w.Header().Set("Digest", "UNKNOWN=abc, SHA-256="+good)
+		case "/bad":
+			w.Header().Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString([]byte("corrupt")))
+		case "/md5":
+			w.Header().Set("Content-MD5", "1B2M2Y8AsgTpgAmY7PhCfg==")
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer ts.Close()
+
+	var v struct{ Name string }
+
+	if err := Navigator(ts.URL + "/bad").Unmarshal(&v); err != nil {
+		t.Errorf("Expected digests to be ignored by default, got %v", err)
+	}
+
+	for _, path := range []string{"/", "/good"} {
+		if err := Navigator(ts.URL + path).VerifyDigest(true).Unmarshal(&v); err != nil {
+			t.Errorf("Expected %s to verify, got %v", path, err)
+		}
+	}
+
+	for _, path := range []string{"/bad", "/md5"} {
+		err := Navigator(ts.URL + path).VerifyDigest(true).Unmarshal(&v)
+		if _, ok := err.(DigestMismatchError); !ok {
+			t.Errorf("Expected DigestMismatchError for %s, got %v", path, err)
+		}
+	}
+
+	var mismatch DigestMismatchError
+	_, err := Navigator(ts.URL + "/bad").VerifyDigest(true).Follow("self").url()
+	if !errors.As(err, &mismatch) {
+		t.Errorf("Expected DigestMismatchError following from a corrupt document, got %v", err)
+	}
+}
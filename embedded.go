@@ -0,0 +1,67 @@
+package halgo
+
+// Embedded represents a collection of HAL embedded resources. You can
+// embed this struct in your own structs, alongside Links, to serialise
+// resources under the "_embedded" key.
+//
+//     type Order struct {
+//       halgo.Links
+//       halgo.Embedded
+//       Total int
+//     }
+//
+//     order := Order{
+//       Links: halgo.Links{}.Self("/orders/1"),
+//       Embedded: halgo.Embedded{}.
+//         Set("customer", customer).
+//         Add("items", item1, item2),
+//     }
+type Embedded struct {
+	Resources map[string]interface{} `json:"_embedded,omitempty"`
+}
+
+// Set embeds a single resource with the relation, which is serialised as
+// a JSON object. Any resources already embedded with the relation are
+// replaced. The receiver isn't modified.
+//
+//     Set("customer", customer)
+func (e Embedded) Set(rel string, resource interface{}) Embedded {
+	other := e.clone()
+	other.Resources[rel] = resource
+
+	return other
+}
+
+// Add embeds resources with the relation as a collection, which is
+// serialised as a JSON array even if it only has one resource. Resources
+// already embedded with the relation are kept before the new ones. The
+// receiver isn't modified.
+//
+//     Add("items", item1, item2)
+func (e Embedded) Add(rel string, resources ...interface{}) Embedded {
+	other := e.clone()
+
+	set := []interface{}{}
+	switch existing := e.Resources[rel].(type) {
+	case nil:
+	case []interface{}:
+		set = append(set, existing...)
+	default:
+		set = append(set, existing)
+	}
+
+	other.Resources[rel] = append(set, resources...)
+
+	return other
+}
+
+// clone returns a copy of the collection which can be modified without
+// affecting e.
+func (e Embedded) clone() Embedded {
+	other := Embedded{Resources: make(map[string]interface{}, len(e.Resources)+1)}
+	for rel, resource := range e.Resources {
+		other.Resources[rel] = resource
+	}
+
+	return other
+}
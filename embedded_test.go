@@ -0,0 +1,60 @@
+package halgo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type embeddedItem struct {
+	Links
+	Name string `json:"name"`
+}
+
+func TestMarshalEmbedded(t *testing.T) {
+	order := struct {
+		Links
+		Embedded
+		Total int `json:"total"`
+	}{
+		Links: Links{}.Self("/orders/1"),
+		Embedded: Embedded{}.
+			Set("customer", embeddedItem{Links{}.Self("/customers/1"), "James"}).
+			Add("items", embeddedItem{Links{}.Self("/items/1"), "Apple"}),
+		Total: 10,
+	}
+
+	b, err := json.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"_links":{"self":{"href":"/orders/1"}},"_embedded":{"customer":{"_links":{"self":{"href":"/customers/1"}},"name":"James"},"items":[{"_links":{"self":{"href":"/items/1"}},"name":"Apple"}]},"total":10}`
+	if string(b) != expected {
+		t.Errorf("Expected %s, got %s", expected, b)
+	}
+}
+
+func TestMarshalWithoutEmbedded(t *testing.T) {
+	b, err := json.Marshal(struct{ Embedded }{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != `{}` {
+		t.Errorf("Expected no _embedded key, got %s", b)
+	}
+}
+
+func TestEmbeddedAddAppendsAndDoesntModifyReceiver(t *testing.T) {
+	base := Embedded{}.Set("item", "a")
+	added := base.Add("item", "b", "c")
+
+	if base.Resources["item"] != "a" {
+		t.Errorf("Expected receiver to be unchanged, got %v", base.Resources["item"])
+	}
+
+	b, _ := json.Marshal(added)
+	if expected := `{"_embedded":{"item":["a","b","c"]}}`; string(b) != expected {
+		t.Errorf("Expected %s, got %s", expected, b)
+	}
+}
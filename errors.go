@@ -1,12 +1,16 @@
 package halgo
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
 
 // LinkNotFoundError is returned when a link with the specified relation
 // couldn't be found in the links collection.
 type LinkNotFoundError struct {
 	rel   string
-	items map[string]linkSet
+	items map[string]LinkSet
 }
 
 func (err LinkNotFoundError) Error() string {
@@ -20,6 +24,96 @@ func (err LinkNotFoundError) Error() string {
 		err.rel, opts)
 }
 
+// NoLinksError is returned when following a relation from a document
+// which has no _links object at all, which usually means it isn't a HAL
+// document.
+type NoLinksError struct {
+	url         string
+	contentType string
+}
+
+func (err NoLinksError) Error() string {
+	return fmt.Sprintf("Document at %s ('%s') has no _links object", err.url, err.contentType)
+}
+
+// AnyLinkNotFoundError is returned when none of several alternative
+// relations could be found in the links collection.
+type AnyLinkNotFoundError struct {
+	rels  []string
+	items map[string]LinkSet
+}
+
+func (err AnyLinkNotFoundError) Error() string {
+	opts := []string{}
+
+	for k := range err.items {
+		opts = append(opts, fmt.Sprintf("'%s'", k))
+	}
+	sort.Strings(opts)
+
+	return fmt.Sprintf("Response didn't contain any of %v link relations: available options were %v",
+		err.rels, opts)
+}
+
+// EmptyLinkSetError is returned when a relation exists but has no links,
+// such as when a document has an empty array for it.
+type EmptyLinkSetError struct {
+	rel string
+}
+
+func (err EmptyLinkSetError) Error() string {
+	return fmt.Sprintf("Response contained '%s' link relation but it had no links", err.rel)
+}
+
+// NoMatchingLinkError is returned when a relation exists but none of its
+// links match the criteria they're being selected by.
+type NoMatchingLinkError struct {
+	rel      string
+	criteria string
+}
+
+func (err NoMatchingLinkError) Error() string {
+	return fmt.Sprintf("No '%s' link matched %s", err.rel, err.criteria)
+}
+
+// TemplateParamsError is returned when following a link strictly and the
+// parameters given don't match the variables of its URI template.
+type TemplateParamsError struct {
+	rel     string
+	unknown []string
+	missing []string
+}
+
+func (err TemplateParamsError) Error() string {
+	return fmt.Sprintf("Parameters for '%s' didn't match its template: unknown %v, missing %v",
+		err.rel, err.unknown, err.missing)
+}
+
+// UnexpectedContentTypeError is returned when a document which should
+// contain links isn't JSON, such as an HTML error page from a proxy.
+type UnexpectedContentTypeError struct {
+	url         string
+	contentType string
+	status      string
+	snippet     string
+}
+
+func (err UnexpectedContentTypeError) Error() string {
+	return fmt.Sprintf("Expected a JSON document from %s but got '%s' (%s): %s",
+		err.url, err.contentType, err.status, err.snippet)
+}
+
+// InvalidLinkError is returned when validating a link which is malformed.
+type InvalidLinkError struct {
+	rel     string
+	href    string
+	problem string
+}
+
+func (err InvalidLinkError) Error() string {
+	return fmt.Sprintf("Invalid '%s' link (%s): %s", err.rel, err.href, err.problem)
+}
+
 // InvalidUrlError is returned when a link contains a malformed or invalid
 // url.
 type InvalidUrlError struct {
@@ -29,3 +123,205 @@ type InvalidUrlError struct {
 func (err InvalidUrlError) Error() string {
 	return fmt.Sprintf("Invalid URL: %s", err.url)
 }
+
+// RootFetchError is returned when the request for the root document
+// fails without a response, which usually means the URI given to
+// Navigator is wrong or the API is unavailable. Error responses and
+// documents which can't be parsed aren't RootFetchErrors.
+type RootFetchError struct {
+	URL string
+	Err error
+}
+
+func (err RootFetchError) Error() string {
+	return fmt.Sprintf("Error fetching root document (%s): %v", err.URL, err.Err)
+}
+
+func (err RootFetchError) Unwrap() error {
+	return err.Err
+}
+
+// FollowTimeoutError is returned when a navigator's timeout expires while
+// fetching the document containing a relation in the follow queue.
+type FollowTimeoutError struct {
+	Rel string
+	URL string
+	Err error
+}
+
+func (err FollowTimeoutError) Error() string {
+	return fmt.Sprintf("Timed out following '%s' from %s: %v", err.Rel, err.URL, err.Err)
+}
+
+func (err FollowTimeoutError) Unwrap() error {
+	return err.Err
+}
+
+// EmbeddedNotFoundError is returned when a document doesn't have a
+// resource embedded with the specified relation.
+type EmbeddedNotFoundError struct {
+	rel string
+	url string
+}
+
+func (err EmbeddedNotFoundError) Error() string {
+	return fmt.Sprintf("Document at %s didn't embed a '%s' resource", err.url, err.rel)
+}
+
+// MaxDepthExceededError is returned when a navigator would follow more
+// relations than its maximum depth allows.
+type MaxDepthExceededError struct {
+	max int
+}
+
+func (err MaxDepthExceededError) Error() string {
+	return fmt.Sprintf("Exceeded maximum follow depth of %d", err.max)
+}
+
+// PageCycleError is returned when paging and a "next" link points to a
+// page which has already been visited.
+type PageCycleError struct {
+	url string
+}
+
+func (err PageCycleError) Error() string {
+	return fmt.Sprintf("Paging cycled back to %s", err.url)
+}
+
+// DigestMismatchError is returned by a navigator verifying digests when
+// a response body doesn't match the length or digest in its headers,
+// which usually means it was corrupted or truncated.
+type DigestMismatchError struct {
+	url    string
+	header string
+}
+
+func (err DigestMismatchError) Error() string {
+	return fmt.Sprintf("Body of %s didn't match its %s header", err.url, err.header)
+}
+
+// ResponseTooLargeError is returned when a response body is longer than
+// the maximum response size of the navigator reading it.
+type ResponseTooLargeError struct {
+	url string
+	max int64
+}
+
+func (err ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("Response from %s was larger than the maximum of %d bytes", err.url, err.max)
+}
+
+// AmbiguousRelationError is returned when relations are being matched
+// case-insensitively and more than one relation matches.
+type AmbiguousRelationError struct {
+	rel     string
+	matches []string
+}
+
+func (err AmbiguousRelationError) Error() string {
+	return fmt.Sprintf("Ambiguous link relation '%s': matched %v", err.rel, err.matches)
+}
+
+// ParallelError is returned when some of the requests made in parallel
+// fail. It has an entry for each request, in the order the requests were
+// given; entries for requests which succeeded are nil.
+type ParallelError []error
+
+func (err ParallelError) Error() string {
+	msgs := []string{}
+
+	for i, e := range err {
+		if e != nil {
+			msgs = append(msgs, fmt.Sprintf("%d: %v", i, e))
+		}
+	}
+
+	return fmt.Sprintf("Parallel requests failed: %s", strings.Join(msgs, "; "))
+}
+
+// FormNotFoundError is returned when a resource doesn't have a HAL-FORMS
+// template with the specified name.
+type FormNotFoundError struct {
+	name      string
+	templates map[string]HalFormTemplate
+}
+
+func (err FormNotFoundError) Error() string {
+	opts := []string{}
+
+	for k := range err.templates {
+		opts = append(opts, fmt.Sprintf("'%s'", k))
+	}
+	sort.Strings(opts)
+
+	return fmt.Sprintf("Response didn't contain '%s' template: available options were %v",
+		err.name, opts)
+}
+
+// FormValidationError is returned when the values submitted with a
+// HAL-FORMS template don't satisfy its properties.
+type FormValidationError struct {
+	problems []string
+}
+
+func (err FormValidationError) Error() string {
+	return fmt.Sprintf("Invalid form values: %s", strings.Join(err.problems, ", "))
+}
+
+// HTTPError is returned by a navigator checking statuses when a response
+// has a 4xx or 5xx status. Body is the start of the response body.
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (err HTTPError) Error() string {
+	return fmt.Sprintf("%s %s failed: %s", err.Method, err.URL, err.Status)
+}
+
+// ProblemDetails is returned instead of a HTTPError when the body of the
+// error response is an RFC 7807 application/problem+json document. The
+// HTTPError is available with errors.As.
+type ProblemDetails struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	HTTPError HTTPError `json:"-"`
+}
+
+func (err ProblemDetails) Error() string {
+	msg := fmt.Sprintf("%s %s failed: %s", err.HTTPError.Method, err.HTTPError.URL, err.HTTPError.Status)
+	if err.Title != "" {
+		msg += ": " + err.Title
+	}
+	if err.Detail != "" {
+		msg += ": " + err.Detail
+	}
+	return msg
+}
+
+func (err ProblemDetails) Unwrap() error {
+	return err.HTTPError
+}
+
+// PreconditionFailedError is returned by a navigator checking statuses
+// when a conditional request fails with 412 Precondition Failed, usually
+// because the resource has changed since its ETag was read. Err is the
+// HTTPError or ProblemDetails for the response.
+type PreconditionFailedError struct {
+	Err error
+}
+
+func (err PreconditionFailedError) Error() string {
+	return err.Err.Error()
+}
+
+func (err PreconditionFailedError) Unwrap() error {
+	return err.Err
+}
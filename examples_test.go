@@ -112,8 +112,8 @@ func ExampleNavigator() {
 func ExampleNavigator_logging() {
 	var me struct{ Username string }
 
-	nav := halgo.Navigator("http://haltalk.herokuapp.com/")
-	nav.HttpClient = halgo.LoggingHttpClient{http.DefaultClient}
+	nav := halgo.Navigator("http://haltalk.herokuapp.com/").
+		WithClient(halgo.LoggingHttpClient{HttpClient: http.DefaultClient})
 
 	nav.Followf("ht:me", halgo.P{"name": "jagregory"}).
 		Unmarshal(&me)
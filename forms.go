@@ -0,0 +1,291 @@
+package halgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// halFormsMediaType is the media type of HAL-FORMS documents.
+const halFormsMediaType = "application/prs.hal-forms+json"
+
+// Forms represents the HAL-FORMS templates of a resource, which describe
+// the actions that can be performed on it. Like Links, you can embed
+// this struct in your own structs.
+//
+//     type MyStruct struct {
+//       halgo.Links
+//       halgo.Forms
+//     }
+//
+// See https://rwcbook.github.io/hal-forms/ for the HAL-FORMS spec.
+type Forms struct {
+	Templates map[string]HalFormTemplate `json:"_templates,omitempty"`
+}
+
+// formsDocument is the Forms of a fetched document, which are read
+// ignoring the rest of the document even when decoded with a Decoder
+// which disallows unknown fields.
+type formsDocument Forms
+
+func (f *formsDocument) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, (*Forms)(f))
+}
+
+// HalFormTemplate is a HAL-FORMS template, which describes a request
+// that can be made against a resource.
+type HalFormTemplate struct {
+	// Title is a human-readable description of the template.
+	Title string `json:"title,omitempty"`
+
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+
+	// ContentType is the media type of the request body. It defaults to
+	// application/json.
+	ContentType string `json:"contentType,omitempty"`
+
+	// Target is the URL the request is made to. It defaults to the URL of
+	// the resource the template belongs to.
+	Target string `json:"target,omitempty"`
+
+	// Properties are the inputs of the template.
+	Properties []HalFormProperty `json:"properties,omitempty"`
+}
+
+// HalFormProperty is an input of a HAL-FORMS template.
+type HalFormProperty struct {
+	Name     string      `json:"name"`
+	Prompt   string      `json:"prompt,omitempty"`
+	Required bool        `json:"required,omitempty"`
+	ReadOnly bool        `json:"readOnly,omitempty"`
+	Type     string      `json:"type,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
+
+	// Regex is a regular expression the value must match.
+	Regex string `json:"regex,omitempty"`
+
+	// Options restricts the value to a list of choices.
+	Options *HalFormOptions `json:"options,omitempty"`
+}
+
+// HalFormOptions are the choices for the value of a HAL-FORMS property.
+// The choices are either listed Inline, or fetched from Link.
+type HalFormOptions struct {
+	Inline         []HalFormOption `json:"inline,omitempty"`
+	Link           *Link           `json:"link,omitempty"`
+	MinItems       int             `json:"minItems,omitempty"`
+	MaxItems       int             `json:"maxItems,omitempty"`
+	SelectedValues []string        `json:"selectedValues,omitempty"`
+}
+
+// HalFormOption is a choice for the value of a HAL-FORMS property.
+type HalFormOption struct {
+	Prompt string `json:"prompt"`
+	Value  string `json:"value"`
+}
+
+// UnmarshalJSON reads an option from either a {prompt, value} object or
+// a plain string, which is used as both the prompt and value.
+func (o *HalFormOption) UnmarshalJSON(d []byte) error {
+	var value string
+	if err := json.Unmarshal(d, &value); err == nil {
+		*o = HalFormOption{Prompt: value, Value: value}
+		return nil
+	}
+
+	var option struct {
+		Prompt string `json:"prompt"`
+		Value  string `json:"value"`
+	}
+	if err := json.Unmarshal(d, &option); err != nil {
+		return err
+	}
+
+	*o = HalFormOption(option)
+	return nil
+}
+
+// Validate checks values satisfy the properties of the template,
+// returning a FormValidationError if they don't.
+func (t HalFormTemplate) Validate(values map[string]interface{}) error {
+	problems := []string{}
+
+	for _, property := range t.Properties {
+		v, ok := values[property.Name]
+		if !ok || v == nil || v == "" {
+			if property.Required {
+				problems = append(problems, fmt.Sprintf("'%s' is required", property.Name))
+			}
+			continue
+		}
+
+		value := fmt.Sprint(v)
+
+		if property.Regex != "" {
+			re, err := regexp.Compile("^(?:" + property.Regex + ")$")
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("'%s' has an invalid regex: %v", property.Name, err))
+			} else if !re.MatchString(value) {
+				problems = append(problems, fmt.Sprintf("'%s' doesn't match %s", property.Name, property.Regex))
+			}
+		}
+
+		if property.Options != nil && len(property.Options.Inline) > 0 && !property.Options.allows(value) {
+			problems = append(problems, fmt.Sprintf("'%s' isn't one of the options", property.Name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return FormValidationError{problems}
+	}
+
+	return nil
+}
+
+// allows returns whether value is one of the inline options.
+func (o HalFormOptions) allows(value string) bool {
+	for _, option := range o.Inline {
+		if option.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// FormSchema performs a GET request on the tip of the follow queue and
+// returns the resource's HAL-FORMS template of the given name, which can
+// be used to render a form or validate values before they're submitted.
+func (n navigator) FormSchema(name string) (HalFormTemplate, error) {
+	n, cancel := n.begin()
+	defer cancel()
+
+	url, err := n.url()
+	if err != nil {
+		return HalFormTemplate{}, err
+	}
+
+	var forms Forms
+	if _, _, err := n.getDocument(url, (*formsDocument)(&forms)); err != nil {
+		return HalFormTemplate{}, err
+	}
+
+	template, ok := forms.Templates[name]
+	if !ok {
+		return HalFormTemplate{}, FormNotFoundError{name, forms.Templates}
+	}
+
+	return template, nil
+}
+
+// SubmitForm performs a GET request on the tip of the follow queue, then
+// submits values with the resource's HAL-FORMS template of the given
+// name. The template decides the method, URL and content type of the
+// request, and values are validated against its properties before
+// anything is sent.
+//
+//     res, err := Navigator("http://api.example.com").
+//       Follow("orders").
+//       SubmitForm("default", map[string]interface{}{"product": 12})
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) SubmitForm(name string, values map[string]interface{}) (*http.Response, error) {
+	n, cancel := n.begin()
+
+	url, err := n.url()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	var forms Forms
+	doc, _, err := n.getDocument(url, (*formsDocument)(&forms))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	url = effectiveURL(doc, url)
+
+	res, err := n.submitForm(url, forms, name, values)
+	return cancelOnClose(res, err, cancel)
+}
+
+// FollowForm adds rel to the follow queue, then submits values with the
+// HAL-FORMS template of the given name from the resulting resource. It's
+// shorthand for Follow(rel).SubmitForm(name, values).
+//
+//     res, err := Navigator("http://api.example.com").
+//       FollowForm("orders", "create", map[string]interface{}{"product": 12})
+func (n navigator) FollowForm(rel, name string, values map[string]interface{}) (*http.Response, error) {
+	return n.Follow(rel).SubmitForm(name, values)
+}
+
+// submitForm submits values with a template from forms, which belong to
+// the resource at base.
+func (n navigator) submitForm(base string, forms Forms, name string, values map[string]interface{}) (*http.Response, error) {
+	n.rootRequest = false
+
+	template, ok := forms.Templates[name]
+	if !ok {
+		return nil, FormNotFoundError{name, forms.Templates}
+	}
+
+	if err := template.Validate(values); err != nil {
+		return nil, err
+	}
+
+	target := base
+	if template.Target != "" {
+		var err error
+		if target, err = makeAbsoluteIfNecessary(template.Target, base); err != nil {
+			return nil, fmt.Errorf("Error making url absolute: %v", err)
+		}
+	}
+
+	method := strings.ToUpper(template.Method)
+	if method == "" {
+		method = "POST"
+	}
+
+	contentType := template.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	body, err := encodeForm(contentType, values)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newHalRequest(method, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return n.doTip(req)
+}
+
+// encodeForm encodes form values as a request body of contentType.
+func encodeForm(contentType string, values map[string]interface{}) (io.Reader, error) {
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		data := url.Values{}
+		for k, v := range values {
+			data.Set(k, fmt.Sprint(v))
+		}
+		return strings.NewReader(data.Encode()), nil
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(b), nil
+}
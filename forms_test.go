@@ -0,0 +1,220 @@
+package halgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type formSubmission struct {
+	method      string
+	contentType string
+	body        map[string]interface{}
+}
+
+func createFormsTestHttpServer() (*httptest.Server, *formSubmission) {
+	submitted := &formSubmission{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{
+        "_links": { "self": { "href": "/orders" } },
+        "_templates": {
+          "default": {
+            "title": "Create order",
+            "method": "post",
+            "contentType": "application/json",
+            "target": "/orders/new",
+            "properties": [
+              { "name": "product", "required": true },
+              { "name": "quantity" }
+            ]
+          },
+          "create": {
+            "method": "POST",
+            "properties": [
+              { "name": "product", "required": true, "regex": "[a-z]+" }
+            ]
+          }
+        }
+      }`)
+			return
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		submitted.method = r.Method + " " + r.URL.Path
+		submitted.contentType = r.Header.Get("Content-Type")
+		json.Unmarshal(b, &submitted.body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	return ts, submitted
+}
+
+func TestUnmarshalForms(t *testing.T) {
+	var res struct {
+		Links
+		Forms
+	}
+
+	err := json.Unmarshal([]byte(`{"_templates":{"default":{"method":"PUT","properties":[{"name":"a","required":true}]}}}`), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template, ok := res.Templates["default"]
+	if !ok {
+		t.Fatal("Expected default template to be unmarshalled")
+	}
+
+	if template.Method != "PUT" || len(template.Properties) != 1 || !template.Properties[0].Required {
+		t.Errorf("Unexpected template %+v", template)
+	}
+}
+
+func TestSubmitForm(t *testing.T) {
+	ts, submitted := createFormsTestHttpServer()
+	defer ts.Close()
+
+	res, err := Navigator(ts.URL).SubmitForm("default", map[string]interface{}{"product": "tea", "quantity": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Expected Created, got %d", res.StatusCode)
+	}
+
+	if submitted.method != "POST /orders/new" {
+		t.Errorf("Expected POST to /orders/new, got %s", submitted.method)
+	}
+
+	if submitted.contentType != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %s", submitted.contentType)
+	}
+
+	if submitted.body["product"] != "tea" || submitted.body["quantity"] != float64(2) {
+		t.Errorf("Unexpected body %v", submitted.body)
+	}
+}
+
+func TestSubmitFormValidatesRequiredProperties(t *testing.T) {
+	ts, submitted := createFormsTestHttpServer()
+	defer ts.Close()
+
+	_, err := Navigator(ts.URL).SubmitForm("default", map[string]interface{}{"quantity": 2})
+	if _, ok := err.(FormValidationError); !ok {
+		t.Errorf("Expected FormValidationError, got %v", err)
+	}
+
+	_, err = Navigator(ts.URL).SubmitForm("missing", nil)
+	if _, ok := err.(FormNotFoundError); !ok {
+		t.Errorf("Expected FormNotFoundError, got %v", err)
+	}
+
+	if submitted.method != "" {
+		t.Errorf("Expected nothing to be submitted, got %s", submitted.method)
+	}
+}
+
+func TestAcceptHalForms(t *testing.T) {
+	accepts := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepts = append(accepts, r.Header.Get("Accept"))
+
+		if strings.HasPrefix(r.Header.Get("Accept"), "application/prs.hal-forms+json") {
+			w.Header().Set("Content-Type", "application/prs.hal-forms+json")
+			fmt.Fprint(w, `{ "_links": { "self": { "href": "/" } }, "_templates": { "default": { "method": "POST" } } }`)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/hal+json")
+		fmt.Fprint(w, `{ "_links": { "self": { "href": "/" } } }`)
+	}))
+	defer ts.Close()
+
+	var res struct{ Forms }
+	if err := Navigator(ts.URL).AcceptHalForms().Follow("self").Unmarshal(&res); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := res.Templates["default"]; !ok {
+		t.Errorf("Expected templates from the HAL-FORMS response, got %v", res.Templates)
+	}
+
+	expected := "application/prs.hal-forms+json, application/hal+json, application/json"
+	for _, accept := range accepts {
+		if accept != expected {
+			t.Errorf("Expected Accept to be %s, got %s", expected, accept)
+		}
+	}
+}
+
+func TestFormSchema(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+      "_templates": {
+        "default": {
+          "method": "PUT",
+          "properties": [
+            { "name": "code", "required": true, "regex": "[A-Z]{3}" },
+            { "name": "size", "options": { "inline": ["small", { "prompt": "Large", "value": "large" }], "maxItems": 1 } }
+          ]
+        }
+      }
+    }`)
+	}))
+	defer ts.Close()
+
+	template, err := Navigator(ts.URL).FormSchema("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, size := template.Properties[0], template.Properties[1]
+
+	if !code.Required || code.Regex != "[A-Z]{3}" {
+		t.Errorf("Expected code to be required with a regex, got %+v", code)
+	}
+
+	expected := []HalFormOption{{"small", "small"}, {"Large", "large"}}
+	if size.Options == nil || fmt.Sprint(size.Options.Inline) != fmt.Sprint(expected) || size.Options.MaxItems != 1 {
+		t.Errorf("Expected size options %v, got %+v", expected, size.Options)
+	}
+
+	if err := template.Validate(map[string]interface{}{"code": "ABC", "size": "large"}); err != nil {
+		t.Errorf("Expected values to be valid, got %v", err)
+	}
+
+	err = template.Validate(map[string]interface{}{"code": "abcd", "size": "medium"})
+	if err == nil || err.Error() != "Invalid form values: 'code' doesn't match [A-Z]{3}, 'size' isn't one of the options" {
+		t.Errorf("Expected values to be invalid, got %v", err)
+	}
+}
+
+func TestFollowForm(t *testing.T) {
+	ts, submitted := createFormsTestHttpServer()
+	defer ts.Close()
+
+	_, err := Navigator(ts.URL).FollowForm("self", "create", map[string]interface{}{"product": "TEA"})
+	if _, ok := err.(FormValidationError); !ok {
+		t.Errorf("Expected FormValidationError, got %v", err)
+	}
+
+	_, err = Navigator(ts.URL).FollowForm("self", "create", map[string]interface{}{"product": "tea"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if submitted.method != "POST /orders" {
+		t.Errorf("Expected POST to /orders, got %s", submitted.method)
+	}
+
+	if submitted.body["product"] != "tea" {
+		t.Errorf("Unexpected body %v", submitted.body)
+	}
+}
@@ -3,6 +3,7 @@ package halgo
 import (
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // HttpClient exposes Do from net/http Client.
@@ -10,14 +11,53 @@ type HttpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// Logger is where LoggingHttpClient writes its log lines. *log.Logger
+// implements it, as can adapters for other logging packages.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
 // LoggingHttpClient is an example HttpClient implementation which wraps
-// an existing HttpClient and prints the request URL to STDOUT whenever
-// one occurs.
+// an existing HttpClient and logs the request URL whenever one occurs.
+// Lines are written to Logger, or to STDOUT if it's nil. If Verbose is
+// set, the status of each response and how long it took is logged once
+// it's received, or the error if the request failed.
+//
+//     nav = nav.WithClient(halgo.LoggingHttpClient{
+//       HttpClient: http.DefaultClient,
+//       Logger:     log.New(os.Stderr, "halgo: ", log.LstdFlags),
+//     })
 type LoggingHttpClient struct {
 	HttpClient
+	Logger  Logger
+	Verbose bool
 }
 
 func (c LoggingHttpClient) Do(req *http.Request) (*http.Response, error) {
-	fmt.Printf("%s %s\n", req.Method, req.URL)
-	return c.HttpClient.Do(req)
+	c.printf("%s %s\n", req.Method, req.URL)
+	if !c.Verbose {
+		return c.HttpClient.Do(req)
+	}
+
+	start := time.Now()
+	res, err := c.HttpClient.Do(req)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		c.printf("%s %s failed after %s: %v\n", req.Method, req.URL, elapsed, err)
+	} else {
+		c.printf("%s %s %s in %s\n", req.Method, req.URL, res.Status, elapsed)
+	}
+
+	return res, err
+}
+
+// printf writes a log line to the Logger, or STDOUT if there isn't one.
+func (c LoggingHttpClient) printf(format string, v ...interface{}) {
+	if c.Logger == nil {
+		fmt.Printf(format, v...)
+		return
+	}
+
+	c.Logger.Printf(format, v...)
 }
@@ -0,0 +1,65 @@
+package halgo
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoggingHttpClientWritesToLogger(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	client := LoggingHttpClient{
+		HttpClient: http.DefaultClient,
+		Logger:     log.New(&buf, "", 0),
+	}
+
+	req, _ := http.NewRequest("GET", ts.URL+"/a", nil)
+	if _, err := client.Do(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "GET " + ts.URL + "/a\n"; buf.String() != expected {
+		t.Errorf("Expected %q to be logged, got %q", expected, buf.String())
+	}
+}
+
+func TestVerboseLoggingHttpClientLogsResponses(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	client := LoggingHttpClient{
+		HttpClient: http.DefaultClient,
+		Logger:     log.New(&buf, "", 0),
+		Verbose:    true,
+	}
+
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	if _, err := client.Do(req); err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ = http.NewRequest("GET", "http://127.0.0.1:0/", nil)
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("Expected an error requesting port 0")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines logged, got %q", lines)
+	}
+	if !strings.HasPrefix(lines[1], "GET "+ts.URL+" 418 I'm a teapot in ") {
+		t.Errorf("Expected the response status to be logged, got %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[3], "GET http://127.0.0.1:0/ failed after ") {
+		t.Errorf("Expected the error to be logged, got %q", lines[3])
+	}
+}
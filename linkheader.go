@@ -0,0 +1,163 @@
+package halgo
+
+import (
+	"strings"
+)
+
+// parseLinkHeader reads the links in the values of RFC 8288 Link headers,
+// such as:
+//
+//     Link: </orders?page=2>; rel="next", </orders>; rel="self first"
+//
+// A link with several relations is added to each of them. The title,
+// type, hreflang, name and profile parameters are read into the link;
+// any others are ignored, as are malformed links.
+func parseLinkHeader(values []string) Links {
+	links := Links{}
+
+	for _, value := range values {
+		p := linkHeaderParser{s: value}
+
+		for {
+			href, params, ok := p.next()
+			if !ok {
+				break
+			}
+
+			link := Link{
+				Href:     href,
+				Title:    params["title"],
+				Type:     params["type"],
+				HrefLang: params["hreflang"],
+				Name:     params["name"],
+				Profile:  params["profile"],
+			}
+			link.Templated = isTemplated(href)
+
+			for _, rel := range strings.Fields(params["rel"]) {
+				links = links.Add(rel, link)
+			}
+		}
+	}
+
+	return links
+}
+
+// linkHeaderParser reads links one at a time from the value of a Link
+// header.
+type linkHeaderParser struct {
+	s   string
+	pos int
+}
+
+// next returns the target and parameters of the next link in the header,
+// or false once there aren't any more.
+func (p *linkHeaderParser) next() (string, map[string]string, bool) {
+	for {
+		p.skip(" \t,")
+		if p.pos >= len(p.s) {
+			return "", nil, false
+		}
+
+		if p.s[p.pos] != '<' {
+			p.skipLink()
+			continue
+		}
+
+		end := strings.IndexByte(p.s[p.pos:], '>')
+		if end < 0 {
+			return "", nil, false
+		}
+
+		href := strings.TrimSpace(p.s[p.pos+1 : p.pos+end])
+		p.pos += end + 1
+
+		return href, p.params(), true
+	}
+}
+
+// params reads the parameters of a link, up to the comma which ends it.
+func (p *linkHeaderParser) params() map[string]string {
+	params := map[string]string{}
+
+	for {
+		p.skip(" \t")
+		if p.pos >= len(p.s) || p.s[p.pos] == ',' {
+			return params
+		}
+
+		if p.s[p.pos] != ';' {
+			p.skipLink()
+			return params
+		}
+		p.pos++
+		p.skip(" \t")
+
+		start := p.pos
+		for p.pos < len(p.s) && !strings.ContainsRune("=;, \t", rune(p.s[p.pos])) {
+			p.pos++
+		}
+		name := strings.ToLower(p.s[start:p.pos])
+
+		p.skip(" \t")
+		if p.pos >= len(p.s) || p.s[p.pos] != '=' {
+			continue
+		}
+		p.pos++
+		p.skip(" \t")
+
+		value := p.value()
+		if _, exists := params[name]; !exists && name != "" {
+			// only the first occurrence of a parameter counts
+			params[name] = value
+		}
+	}
+}
+
+// value reads a parameter value, which is either a token or a quoted
+// string.
+func (p *linkHeaderParser) value() string {
+	if p.pos >= len(p.s) || p.s[p.pos] != '"' {
+		start := p.pos
+		for p.pos < len(p.s) && !strings.ContainsRune(";, \t", rune(p.s[p.pos])) {
+			p.pos++
+		}
+		return p.s[start:p.pos]
+	}
+
+	var buf strings.Builder
+	for p.pos++; p.pos < len(p.s); p.pos++ {
+		switch c := p.s[p.pos]; {
+		case c == '\\' && p.pos+1 < len(p.s):
+			p.pos++
+			buf.WriteByte(p.s[p.pos])
+		case c == '"':
+			p.pos++
+			return buf.String()
+		default:
+			buf.WriteByte(c)
+		}
+	}
+
+	return buf.String()
+}
+
+// skip moves past any of chars.
+func (p *linkHeaderParser) skip(chars string) {
+	for p.pos < len(p.s) && strings.IndexByte(chars, p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// skipLink moves past a malformed link, to the comma which ends it.
+func (p *linkHeaderParser) skipLink() {
+	inQuotes := false
+	for ; p.pos < len(p.s); p.pos++ {
+		switch c := p.s[p.pos]; {
+		case c == '"':
+			inQuotes = !inQuotes
+		case c == ',' && !inQuotes:
+			return
+		}
+	}
+}
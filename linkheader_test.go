@@ -0,0 +1,42 @@
+package halgo
+
+import (
+	"testing"
+)
+
+func TestParseLinkHeader(t *testing.T) {
+	links := parseLinkHeader([]string{
+		`</orders?page=2>; rel="next"; title="Next page", </orders>;rel="self first"`,
+		`<http://example.com/find{?q}>; REL=search; type="application/hal+json"; hreflang=en`,
+		`not a link, </help>; rel=help; title="Say \"hi\", then leave"; rel=ignored`,
+	})
+
+	expected := map[string]Link{
+		"next":   {Href: "/orders?page=2", Title: "Next page"},
+		"self":   {Href: "/orders"},
+		"first":  {Href: "/orders"},
+		"search": {Href: "http://example.com/find{?q}", Templated: true, Type: "application/hal+json", HrefLang: "en"},
+		"help":   {Href: "/help", Title: `Say "hi", then leave`},
+	}
+
+	if len(links.Items) != len(expected) {
+		t.Errorf("Expected relations %v, got %v", len(expected), links.Rels())
+	}
+
+	for rel, link := range expected {
+		set, ok := links.LinkSet(rel)
+		if !ok || len(set) != 1 || !set[0].Equal(link) {
+			t.Errorf("Expected %s to be %+v, got %+v", rel, link, set)
+		}
+	}
+}
+
+func TestParseEmptyLinkHeader(t *testing.T) {
+	if links := parseLinkHeader(nil); len(links.Items) != 0 {
+		t.Errorf("Expected no links, got %v", links.Items)
+	}
+
+	if links := parseLinkHeader([]string{"</a>", "</b>; title=b"}); len(links.Items) != 0 {
+		t.Errorf("Expected links without relations to be ignored, got %v", links.Items)
+	}
+}
@@ -1,14 +1,21 @@
 package halgo
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/jtacoma/uritemplates"
+	"net/url"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // Links represents a collection of HAL links. You can embed this struct
-// in your own structs for sweet, sweet HAL serialisation goodness.
+// in your own structs for sweet, sweet HAL serialisation goodness. Links
+// are always serialised ordered by relation, as encoding/json sorts map
+// keys.
 //
 //     type MyStruct struct {
 //       halgo.Links
@@ -20,7 +27,7 @@ import (
 //         Next("http://example.com/1"),
 //     }
 type Links struct {
-	Items map[string]linkSet `json:"_links,omitempty"`
+	Items map[string]LinkSet `json:"_links,omitempty"`
 	// Curies CurieSet
 }
 
@@ -61,26 +68,31 @@ func (l Links) Link(rel, href string, args ...interface{}) Links {
 		href = fmt.Sprintf(href, args...)
 	}
 
-	templated, _ := regexp.Match("{.*?}", []byte(href))
-
-	return l.Add(rel, Link{Href: href, Templated: templated})
+	return l.Add(rel, Link{Href: href, Templated: isTemplated(href)})
 }
 
 // Add creates multiple links with the same relation.
 //
 //     Add("abc", halgo.Link{Href: "/a/1"}, halgo.Link{Href: "/a/2"})
 func (l Links) Add(rel string, links ...Link) Links {
+	return l.join(rel, links, l.Items[rel].alwaysArray())
+}
+
+// join adds links to the relation rel, like Add, serialising the whole
+// relation as an array if array is set. encoding/json only hands a
+// relation's links to LinkSet.MarshalJSON, so every link carries its
+// relation's array setting, whichever relation it came from.
+func (l Links) join(rel string, links []Link, array bool) Links {
 	if l.Items == nil {
-		l.Items = make(map[string]linkSet)
+		l.Items = make(map[string]LinkSet)
 	}
 
-	set, exists := l.Items[rel]
+	set := make([]Link, 0, len(l.Items[rel])+len(links))
+	set = append(set, l.Items[rel]...)
+	set = append(set, links...)
 
-	if exists {
-		set = append(set, links...)
-	} else {
-		set = make([]Link, len(links))
-		copy(set, links)
+	for i := range set {
+		set[i].alwaysArray = array
 	}
 
 	l.Items[rel] = set
@@ -88,11 +100,93 @@ func (l Links) Add(rel string, links ...Link) Links {
 	return l
 }
 
+// AddArray creates multiple links with the same relation, like Add, and
+// marks the relation to always be serialised as a JSON array, even when
+// it only has one link. Links added later with Add to a relation which
+// already has links from AddArray are serialised as an array too. For
+// consumers which can't handle a relation being either an object or an
+// array.
+//
+//     AddArray("item", halgo.Link{Href: "/a/1"})
+func (l Links) AddArray(rel string, links ...Link) Links {
+	return l.join(rel, links, true)
+}
+
+// Merge creates a new collection with the links of both collections.
+// Where both have links with the same relation, the links from other are
+// appended to the links from l. Neither collection is modified.
+//
+//     Links{}.Self("/a").Merge(Links{}.Next("/b"))
+func (l Links) Merge(other Links) Links {
+	merged := Links{Items: make(map[string]LinkSet, len(l.Items)+len(other.Items))}
+
+	for rel, set := range l.Items {
+		merged = merged.join(rel, set, set.alwaysArray())
+	}
+
+	for rel, set := range other.Items {
+		merged = merged.join(rel, set, merged.Items[rel].alwaysArray() || set.alwaysArray())
+	}
+
+	return merged
+}
+
+// Remove creates a new collection without any links with the supplied
+// relation. The receiver isn't modified.
+//
+//     Remove("next")
+func (l Links) Remove(rel string) Links {
+	return l.RemoveWhere(rel, func(Link) bool { return true })
+}
+
+// RemoveWhere creates a new collection without the links with the
+// supplied relation which match pred. If every link of the relation is
+// removed, the relation is removed too. The receiver isn't modified.
+//
+//     RemoveWhere("item", func(l halgo.Link) bool { return l.Name == "old" })
+func (l Links) RemoveWhere(rel string, pred func(Link) bool) Links {
+	other := Links{}
+
+	for r, set := range l.Items {
+		if r != rel {
+			other = other.join(r, set, set.alwaysArray())
+			continue
+		}
+
+		kept := []Link{}
+		for _, link := range set {
+			if !pred(link) {
+				kept = append(kept, link)
+			}
+		}
+
+		if len(kept) > 0 {
+			other = other.join(r, kept, set.alwaysArray())
+		}
+	}
+
+	return other
+}
+
 // P is a parameters map for expanding URL templates.
 //
 //     halgo.P{"id": 1}
 type P map[string]interface{}
 
+// clone returns a copy of the parameters, so the copy is unaffected by
+// any later changes to p.
+func (p P) clone() P {
+	if p == nil {
+		return nil
+	}
+
+	other := make(P, len(p))
+	for k, v := range p {
+		other[k] = v
+	}
+	return other
+}
+
 // Href tries to find the href of a link with the supplied relation.
 // Returns LinkNotFoundError if a link doesn't exist.
 func (l Links) Href(rel string) (string, error) {
@@ -101,19 +195,304 @@ func (l Links) Href(rel string) (string, error) {
 
 // HrefParams tries to find the href of a link with the supplied relation,
 // then expands any URI template parameters. Returns LinkNotFoundError if
-// a link doesn't exist.
+// the relation doesn't exist, or EmptyLinkSetError if it has no links.
 func (l Links) HrefParams(rel string, params P) (string, error) {
+	return l.HrefParamsWith(rel, params, ExpandTemplate)
+}
+
+// HrefParamsWith is like HrefParams, but expands the href of the link
+// with expand rather than as an RFC 6570 URI template.
+func (l Links) HrefParamsWith(rel string, params P, expand TemplateExpander) (string, error) {
 	if rel == "" {
 		return "", errors.New("Empty string not valid relation")
 	}
 
-	links := l.Items[rel]
-	if len(links) > 0 {
-		link := links[0] // TODO: handle multiple here
-		return link.Expand(params)
+	links, ok := l.Items[rel]
+	if !ok {
+		return "", LinkNotFoundError{rel, l.Items}
+	}
+	if len(links) == 0 {
+		return "", EmptyLinkSetError{rel}
+	}
+
+	link := links[0] // TODO: handle multiple here
+	return expand(link.Href, params)
+}
+
+// Validate checks every link in the collection has an href which is a
+// valid URL, or a valid URI template if the link is templated, and that
+// templated links contain template expressions. An InvalidLinkError is
+// returned for each link with a problem, ordered by relation.
+func (l Links) Validate() []error {
+	errs := []error{}
+
+	for _, rel := range l.Rels() {
+		for _, link := range l.Items[rel] {
+			if problem := link.validate(); problem != "" {
+				errs = append(errs, InvalidLinkError{rel, link.Href, problem})
+			}
+		}
 	}
 
-	return "", LinkNotFoundError{rel, l.Items}
+	return errs
+}
+
+// IsTemplated returns whether the link with the supplied relation is a
+// URI template, which needs parameters to expand. Returns
+// LinkNotFoundError if the relation doesn't exist, or EmptyLinkSetError
+// if it has no links.
+func (l Links) IsTemplated(rel string) (bool, error) {
+	links, ok := l.Items[rel]
+	if !ok {
+		return false, LinkNotFoundError{rel, l.Items}
+	}
+	if len(links) == 0 {
+		return false, EmptyLinkSetError{rel}
+	}
+
+	return links[0].Templated, nil // TODO: handle multiple here
+}
+
+// LinkSet returns every link with the supplied relation, and whether the
+// relation exists.
+func (l Links) LinkSet(rel string) (LinkSet, bool) {
+	set, ok := l.Items[rel]
+	return set, ok
+}
+
+// Rels returns the relations in the collection, sorted by name.
+func (l Links) Rels() []string {
+	rels := []string{}
+	for rel := range l.Items {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+
+	return rels
+}
+
+// HrefParamsIgnoreCase is like HrefParams, but matches the relation
+// ignoring case when there's no exact match, for APIs which aren't
+// consistent about their relation names. Returns AmbiguousRelationError
+// if several relations match rel only when ignoring case.
+func (l Links) HrefParamsIgnoreCase(rel string, params P) (string, error) {
+	if rel == "" {
+		return "", errors.New("Empty string not valid relation")
+	}
+
+	match, err := l.foldRel(rel)
+	if err != nil {
+		return "", err
+	}
+
+	return l.HrefParams(match, params)
+}
+
+// foldRel returns the name of the relation which matches rel exactly, or
+// failing that the only relation which matches it ignoring case.
+func (l Links) foldRel(rel string) (string, error) {
+	if _, ok := l.Items[rel]; ok {
+		return rel, nil
+	}
+
+	matches := []string{}
+	for k := range l.Items {
+		if strings.EqualFold(k, rel) {
+			matches = append(matches, k)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", LinkNotFoundError{rel, l.Items}
+	case 1:
+		return matches[0], nil
+	}
+
+	sort.Strings(matches)
+	return "", AmbiguousRelationError{rel, matches}
+}
+
+// ExpandDetailed finds the href of a link with the supplied relation and
+// expands any URI template parameters, like HrefParams, and also returns
+// the names of the params which were used by the template and those which
+// weren't, both sorted. It's useful for diagnosing why a URL came out
+// differently than expected.
+func (l Links) ExpandDetailed(rel string, params P) (url string, used []string, unused []string, err error) {
+	url, err = l.HrefParams(rel, params)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	names, err := templateNames(l.Items[rel][0].Href)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	used, unused = []string{}, []string{}
+	for name := range params {
+		if names[name] {
+			used = append(used, name)
+		} else {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(used)
+	sort.Strings(unused)
+
+	return url, used, unused, nil
+}
+
+// templateNames returns the names of the variables in the URI template
+// href.
+func templateNames(href string) (map[string]bool, error) {
+	template, err := uritemplates.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for _, name := range template.Names() {
+		names[name] = true
+	}
+
+	return names, nil
+}
+
+// HrefByProfile tries to find the href of a link with the supplied
+// relation and profile, then expands any URI template parameters.
+// Returns LinkNotFoundError if the relation doesn't exist,
+// EmptyLinkSetError if it has no links, or NoMatchingLinkError if none of
+// its links have the profile.
+func (l Links) HrefByProfile(rel, profile string, params P) (string, error) {
+	links, ok := l.Items[rel]
+	if !ok {
+		return "", LinkNotFoundError{rel, l.Items}
+	}
+	if len(links) == 0 {
+		return "", EmptyLinkSetError{rel}
+	}
+
+	link, ok := l.find(rel, func(link Link) bool { return link.Profile == profile })
+	if !ok {
+		return "", NoMatchingLinkError{rel, fmt.Sprintf("profile '%s'", profile)}
+	}
+
+	return link.Expand(params)
+}
+
+// HrefByLang tries to find the href of a link with the supplied relation
+// and hreflang, then expands any URI template parameters. Languages are
+// compared ignoring case. Returns LinkNotFoundError if the relation
+// doesn't exist, EmptyLinkSetError if it has no links, or
+// NoMatchingLinkError if none of its links are in the language.
+func (l Links) HrefByLang(rel, lang string, params P) (string, error) {
+	links, ok := l.Items[rel]
+	if !ok {
+		return "", LinkNotFoundError{rel, l.Items}
+	}
+	if len(links) == 0 {
+		return "", EmptyLinkSetError{rel}
+	}
+
+	link, ok := l.find(rel, func(link Link) bool { return strings.EqualFold(link.HrefLang, lang) })
+	if !ok {
+		return "", NoMatchingLinkError{rel, fmt.Sprintf("hreflang '%s'", lang)}
+	}
+
+	return link.Expand(params)
+}
+
+// find returns the first link with the supplied relation which satisfies
+// match.
+func (l Links) find(rel string, match func(Link) bool) (Link, bool) {
+	for _, link := range l.Items[rel] {
+		if match(link) {
+			return link, true
+		}
+	}
+
+	return Link{}, false
+}
+
+// DeprecatedLink is a link which has been marked as deprecated.
+type DeprecatedLink struct {
+	// Rel is the relation of the link.
+	Rel string
+
+	// Href is the href of the link.
+	Href string
+
+	// Deprecation is the URL of information about the deprecation.
+	Deprecation string
+}
+
+// Deprecations returns every link which has its Deprecation set, ordered
+// by relation.
+func (l Links) Deprecations() []DeprecatedLink {
+	deprecated := []DeprecatedLink{}
+	for _, rel := range l.Rels() {
+		for _, link := range l.Items[rel] {
+			if link.Deprecation != "" {
+				deprecated = append(deprecated, DeprecatedLink{rel, link.Href, link.Deprecation})
+			}
+		}
+	}
+
+	return deprecated
+}
+
+// Curie is a CURIE definition from the "curies" relation of a document,
+// which gives the documentation for compact relations such as
+// "ea:orders".
+type Curie struct {
+	// Name is the prefix of the compact relations it defines, such as
+	// "ea".
+	Name string
+
+	// Href is the URI template of the documentation, where {rel} is the
+	// rest of the compact relation.
+	Href string
+}
+
+// Curies returns the CURIE definitions in the "curies" relation, in the
+// order they appear. Links without a name aren't CURIEs and are left out.
+//
+//     for _, curie := range links.Curies() {
+//       fmt.Println(curie.Name, curie.Href)
+//     }
+func (l Links) Curies() []Curie {
+	curies := []Curie{}
+	for _, link := range l.Items["curies"] {
+		if link.Name != "" {
+			curies = append(curies, Curie{link.Name, link.Href})
+		}
+	}
+
+	return curies
+}
+
+// curiePrefix returns the prefix of a compact relation such as
+// "ea:orders", or false if rel isn't one. Relations which are URLs, such
+// as "http://example.com/rels/orders", aren't compact.
+func curiePrefix(rel string) (string, bool) {
+	i := strings.Index(rel, ":")
+	if i <= 0 || strings.HasPrefix(rel[i+1:], "//") {
+		return "", false
+	}
+
+	return rel[:i], true
+}
+
+// definesCurie returns whether the collection has a CURIE named prefix.
+func (l Links) definesCurie(prefix string) bool {
+	for _, curie := range l.Curies() {
+		if curie.Name == prefix {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Link represents a HAL link
@@ -166,14 +545,144 @@ type Link struct {
 	// Its value is a string and is intended for indicating the language of
 	// the target resource (as defined by [RFC5988]).
 	HrefLang string `json:"hreflang,omitempty"`
+
+	// alwaysArray serialises the link's relation as an array even when
+	// it only has one link. It's the only record of a relation added with
+	// Links.AddArray, and Links sets it on every link of the relation.
+	alwaysArray bool
+}
+
+// UnmarshalJSON reads a link, setting Templated if the href is a URI
+// template even when the document doesn't say so. Servers commonly leave
+// out "templated", which would otherwise leave the link unexpandable.
+func (l *Link) UnmarshalJSON(d []byte) error {
+	type link Link
+
+	var v link
+	if err := json.Unmarshal(d, &v); err != nil {
+		return err
+	}
+
+	*l = Link(v)
+	if !l.Templated {
+		l.Templated = isTemplated(l.Href)
+	}
+
+	return nil
+}
+
+// isTemplated returns whether href contains any URI template expressions.
+func isTemplated(href string) bool {
+	templated, _ := regexp.MatchString("{.*?}", href)
+	return templated
+}
+
+// validate describes what's wrong with the link, if anything.
+func (l Link) validate() string {
+	if l.Href == "" {
+		return "href is empty"
+	}
+
+	if !l.Templated {
+		if _, err := url.Parse(l.Href); err != nil {
+			return fmt.Sprintf("href isn't a valid URL: %v", err)
+		}
+		return ""
+	}
+
+	template, err := uritemplates.Parse(l.Href)
+	if err != nil {
+		return fmt.Sprintf("href isn't a valid URI template: %v", err)
+	}
+
+	if len(template.Names()) == 0 {
+		return "link is templated but href has no template expressions"
+	}
+
+	return ""
+}
+
+// Equal returns whether both links have the same values for every
+// property, like ==. A link of a relation added with Links.AddArray isn't
+// equal to the same link of a relation added with Add, as they're
+// serialised differently.
+func (l Link) Equal(other Link) bool {
+	return l == other
 }
 
 // Expand will expand the URL template of the link with the given params.
 func (l Link) Expand(params P) (string, error) {
-	template, err := uritemplates.Parse(l.Href)
+	return ExpandTemplate(l.Href, params)
+}
+
+// TemplateExpander expands the URI template href with params. It can be
+// supplied to a navigator or Links.HrefParamsWith to expand links which
+// use a templating scheme other than RFC 6570.
+type TemplateExpander func(href string, params P) (string, error)
+
+// ExpandTemplate is the default TemplateExpander, which expands href as an
+// RFC 6570 URI template. Floating point params are expanded in plain
+// decimal, such as 0.000001 rather than 1e-06.
+func ExpandTemplate(href string, params P) (string, error) {
+	template, err := uritemplates.Parse(href)
 	if err != nil {
 		return "", err
 	}
 
-	return template.Expand(map[string]interface{}(params))
+	values := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		values[k] = templateValue(v)
+	}
+
+	return template.Expand(values)
+}
+
+// templateValue formats floats in v, including those in lists and maps,
+// as plain decimal strings, which would otherwise be expanded with %v and
+// could use an exponent.
+func templateValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case []float64:
+		values := make([]interface{}, len(v))
+		for i, f := range v {
+			values[i] = templateValue(f)
+		}
+		return values
+	case []float32:
+		values := make([]interface{}, len(v))
+		for i, f := range v {
+			values[i] = templateValue(f)
+		}
+		return values
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = templateValue(item)
+		}
+		return values
+	case map[string]float64:
+		values := make(map[string]interface{}, len(v))
+		for k, f := range v {
+			values[k] = templateValue(f)
+		}
+		return values
+	case map[string]float32:
+		values := make(map[string]interface{}, len(v))
+		for k, f := range v {
+			values[k] = templateValue(f)
+		}
+		return values
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			values[k] = templateValue(item)
+		}
+		return values
+	}
+
+	return v
 }
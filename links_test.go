@@ -2,6 +2,9 @@ package halgo
 
 import (
 	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -120,3 +123,528 @@ func TestAutoSettingOfTemplated(t *testing.T) {
 		t.Error("not-templated should have Templated=true")
 	}
 }
+
+func TestHrefByProfile(t *testing.T) {
+	l := Links{}.Add("item",
+		Link{Href: "/items/{id}", Profile: "http://example.com/profiles/v1"},
+		Link{Href: "/v2/items/{id}", Profile: "http://example.com/profiles/v2"})
+
+	href, err := l.HrefByProfile("item", "http://example.com/profiles/v2", P{"id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "/v2/items/1"; href != expected {
+		t.Errorf("Expected item to be %s, got %s", expected, href)
+	}
+
+	_, err = l.HrefByProfile("item", "http://example.com/profiles/v3", nil)
+	if _, ok := err.(NoMatchingLinkError); !ok {
+		t.Errorf("Expected NoMatchingLinkError, got %v", err)
+	}
+
+	_, err = l.HrefByProfile("missing", "http://example.com/profiles/v1", nil)
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Errorf("Expected LinkNotFoundError, got %v", err)
+	}
+}
+
+func TestHrefMissingAndEmptyRelations(t *testing.T) {
+	var l Links
+	if err := json.Unmarshal([]byte(`{ "_links": { "items": [] } }`), &l); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := l.Href("missing")
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Errorf("Expected LinkNotFoundError, got %v", err)
+	}
+
+	_, err = l.Href("items")
+	if _, ok := err.(EmptyLinkSetError); !ok {
+		t.Errorf("Expected EmptyLinkSetError, got %v", err)
+	}
+
+	_, err = l.HrefByProfile("items", "http://example.com/profiles/v1", nil)
+	if _, ok := err.(EmptyLinkSetError); !ok {
+		t.Errorf("Expected EmptyLinkSetError, got %v", err)
+	}
+}
+
+func TestHrefByLang(t *testing.T) {
+	l := Links{}.Add("help",
+		Link{Href: "/help/en", HrefLang: "en"},
+		Link{Href: "/help/de", HrefLang: "de-DE"})
+
+	href, err := l.HrefByLang("help", "de-de", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "/help/de"; href != expected {
+		t.Errorf("Expected help to be %s, got %s", expected, href)
+	}
+
+	_, err = l.HrefByLang("help", "fr", nil)
+	if _, ok := err.(NoMatchingLinkError); !ok {
+		t.Errorf("Expected NoMatchingLinkError, got %v", err)
+	}
+
+	_, err = l.HrefByLang("missing", "en", nil)
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Errorf("Expected LinkNotFoundError, got %v", err)
+	}
+}
+
+func TestHrefParamsWith(t *testing.T) {
+	l := Links{}.Link("item", "/items/{id=1}")
+
+	expand := func(href string, params P) (string, error) {
+		id, ok := params["id"]
+		if !ok {
+			id = "1"
+		}
+		return strings.Replace(href, "{id=1}", fmt.Sprint(id), 1), nil
+	}
+
+	if href, _ := l.HrefParamsWith("item", nil, expand); href != "/items/1" {
+		t.Errorf("Expected default to be expanded, got %s", href)
+	}
+
+	if href, _ := l.HrefParamsWith("item", P{"id": 2}, expand); href != "/items/2" {
+		t.Errorf("Expected id to be expanded, got %s", href)
+	}
+}
+
+func TestHrefParamsNumbers(t *testing.T) {
+	l := Links{}.Link("search", "/search{?page,id,price,ratio,range*}")
+
+	href, err := l.HrefParams("search", P{
+		"page":  1,
+		"id":    int64(9007199254740993),
+		"price": 12345678.9,
+		"ratio": float32(0.000001),
+		"range": []float64{1e21, 0.5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "/search?page=1&id=9007199254740993&price=12345678.9&ratio=0.000001&range=1000000000000000000000&range=0.5"
+	if href != expected {
+		t.Errorf("Expected %s, got %s", expected, href)
+	}
+}
+
+func TestHrefParamsFloatsInListsAndMaps(t *testing.T) {
+	l := Links{}.Link("search", "/search{?sizes*,bounds*,box*,near*}")
+
+	href, err := l.HrefParams("search", P{
+		"sizes":  []float32{0.000001, 2.5},
+		"bounds": map[string]interface{}{"max": 1e21},
+		"box":    map[string]float64{"min": 0.0000001},
+		"near":   map[string]float32{"lat": 0.000001},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "/search?sizes=0.000001&sizes=2.5&max=1000000000000000000000&min=0.0000001&lat=0.000001"
+	if href != expected {
+		t.Errorf("Expected %s, got %s", expected, href)
+	}
+}
+
+func TestExpandDetailed(t *testing.T) {
+	l := Links{}.Link("search", "/search{?q,page}")
+
+	url, used, unused, err := l.ExpandDetailed("search", P{"q": "halgo", "sort": "asc", "limit": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if url != "/search?q=halgo" {
+		t.Errorf("Expected url /search?q=halgo, got %s", url)
+	}
+	if fmt.Sprint(used) != "[q]" {
+		t.Errorf("Expected used [q], got %v", used)
+	}
+	if fmt.Sprint(unused) != "[limit sort]" {
+		t.Errorf("Expected unused [limit sort], got %v", unused)
+	}
+
+	if _, _, _, err := l.ExpandDetailed("missing", nil); err == nil {
+		t.Errorf("Expected an error for a missing relation")
+	}
+}
+
+func TestHrefParamsIgnoreCase(t *testing.T) {
+	l := Links{}.
+		Link("Next", "/2").
+		Link("Item", "/a/1").
+		Link("ITEM", "/a/2")
+
+	if _, err := l.Href("next"); err == nil {
+		t.Error("Expected Href to match case exactly")
+	}
+
+	if href, err := l.HrefParamsIgnoreCase("next", nil); err != nil || href != "/2" {
+		t.Errorf("Expected next to match Next, got %s, %v", href, err)
+	}
+
+	if href, err := l.HrefParamsIgnoreCase("ITEM", nil); err != nil || href != "/a/2" {
+		t.Errorf("Expected an exact match to win, got %s, %v", href, err)
+	}
+
+	_, err := l.HrefParamsIgnoreCase("item", nil)
+	if _, ok := err.(AmbiguousRelationError); !ok {
+		t.Errorf("Expected AmbiguousRelationError, got %v", err)
+	}
+
+	_, err = l.HrefParamsIgnoreCase("prev", nil)
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Errorf("Expected LinkNotFoundError, got %v", err)
+	}
+}
+
+func TestLinkSet(t *testing.T) {
+	l := Links{}.Add("item", Link{Href: "/a/1", Title: "One"}, Link{Href: "/a/2", Title: "Two"})
+
+	set, ok := l.LinkSet("item")
+	if !ok || len(set) != 2 || set[1].Title != "Two" {
+		t.Errorf("Expected both item links, got %v, %v", set, ok)
+	}
+
+	if _, ok := l.LinkSet("missing"); ok {
+		t.Error("Expected missing relation not to exist")
+	}
+}
+
+func TestIsTemplated(t *testing.T) {
+	l := Links{}.Self("/orders").Link("find", "/orders{?id}")
+
+	if templated, err := l.IsTemplated("find"); err != nil || !templated {
+		t.Errorf("Expected find to be templated, got %v, %v", templated, err)
+	}
+
+	if templated, err := l.IsTemplated("self"); err != nil || templated {
+		t.Errorf("Expected self not to be templated, got %v, %v", templated, err)
+	}
+
+	if _, err := l.IsTemplated("missing"); err == nil {
+		t.Error("Expected an error for a missing relation")
+	}
+}
+
+func TestMergeLinks(t *testing.T) {
+	a := Links{}.Self("/a").Link("item", "/items/1")
+	b := Links{}.Next("/b").Link("item", "/items/2")
+
+	merged := a.Merge(b)
+
+	if v, _ := merged.Href("self"); v != "/a" {
+		t.Errorf("Expected self to be /a, got %s", v)
+	}
+
+	if v, _ := merged.Href("next"); v != "/b" {
+		t.Errorf("Expected next to be /b, got %s", v)
+	}
+
+	if items := merged.Items["item"]; len(items) != 2 || items[0].Href != "/items/1" || items[1].Href != "/items/2" {
+		t.Errorf("Expected item links to be appended, got %v", items)
+	}
+
+	if len(a.Items) != 2 || len(a.Items["item"]) != 1 {
+		t.Errorf("Expected receiver to be unchanged, got %v", a.Items)
+	}
+
+	if len(b.Items) != 2 || len(b.Items["item"]) != 1 {
+		t.Errorf("Expected argument to be unchanged, got %v", b.Items)
+	}
+}
+
+func TestRemoveLinks(t *testing.T) {
+	l := Links{}.
+		Self("/orders").
+		Add("item", Link{Href: "/items/1", Name: "one"}, Link{Href: "/items/2", Name: "two"})
+
+	removed := l.Remove("self")
+	if _, err := removed.Href("self"); err == nil {
+		t.Error("Expected self to be removed")
+	}
+	if len(removed.Items["item"]) != 2 {
+		t.Errorf("Expected items to be kept, got %v", removed.Items["item"])
+	}
+	if _, err := l.Href("self"); err != nil {
+		t.Error("Expected receiver to be unchanged")
+	}
+
+	removed = l.RemoveWhere("item", func(link Link) bool { return link.Name == "one" })
+	if items := removed.Items["item"]; len(items) != 1 || items[0].Name != "two" {
+		t.Errorf("Expected only item two to remain, got %v", items)
+	}
+	if len(l.Items["item"]) != 2 {
+		t.Error("Expected receiver to be unchanged")
+	}
+
+	removed = l.RemoveWhere("item", func(Link) bool { return true })
+	if _, ok := removed.Items["item"]; ok {
+		t.Error("Expected item relation to be removed when it has no links left")
+	}
+
+	if removed = l.Remove("missing"); len(removed.Items) != len(l.Items) {
+		t.Errorf("Expected removing a missing relation to be a no-op, got %v", removed.Items)
+	}
+}
+
+func TestLinksRels(t *testing.T) {
+	l := Links{}.Self("/").Next("/2").Link("ea:find", "/find{?q}")
+
+	if rels := fmt.Sprint(l.Rels()); rels != "[ea:find next self]" {
+		t.Errorf("Expected sorted relations, got %s", rels)
+	}
+
+	if rels := (Links{}).Rels(); len(rels) != 0 {
+		t.Errorf("Expected no relations, got %v", rels)
+	}
+}
+
+func TestLinkEqual(t *testing.T) {
+	a := Link{Href: "/a", Title: "A", Templated: false}
+
+	if !a.Equal(Link{Href: "/a", Title: "A"}) {
+		t.Error("Expected links with the same properties to be equal")
+	}
+
+	if a.Equal(Link{Href: "/a", Title: "B"}) {
+		t.Error("Expected links with different titles not to be equal")
+	}
+
+	if a.Equal(Link{Href: "/a", Title: "A", HrefLang: "en"}) {
+		t.Error("Expected links with different hreflangs not to be equal")
+	}
+}
+
+func TestLinkSetEqual(t *testing.T) {
+	one, two := Link{Href: "/1"}, Link{Href: "/2"}
+
+	if !(LinkSet{one, two}).Equal(LinkSet{two, one}) {
+		t.Error("Expected sets with the same links in a different order to be equal")
+	}
+
+	if (LinkSet{one, one}).Equal(LinkSet{one, two}) {
+		t.Error("Expected sets with different links not to be equal")
+	}
+
+	if (LinkSet{one}).Equal(LinkSet{one, two}) {
+		t.Error("Expected sets of different sizes not to be equal")
+	}
+
+	if !(LinkSet{}).Equal(nil) {
+		t.Error("Expected empty sets to be equal")
+	}
+}
+
+func TestMarshalLinksIsSortedByRelation(t *testing.T) {
+	l := Links{}.
+		Link("zebra", "/z").
+		Link("apple", "/a").
+		Link("mango", "/m").
+		Link("ea:find", "/f").
+		Link("Banana", "/b").
+		Link("cherry", "/c")
+
+	b, err := json.Marshal(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"_links":{"Banana":{"href":"/b"},"apple":{"href":"/a"},"cherry":{"href":"/c"},"ea:find":{"href":"/f"},"mango":{"href":"/m"},"zebra":{"href":"/z"}}}`
+	if string(b) != expected {
+		t.Errorf("Unexpected JSON %s", b)
+	}
+}
+
+func TestLinkSetOrderSurvivesRoundTrip(t *testing.T) {
+	input := `{"_links":{"item":[{"href":"/items/3"},{"href":"/items/1"},{"href":"/items/2","name":"b"},{"href":"/items/2","name":"a"}]}}`
+
+	var l Links
+	if err := json.Unmarshal([]byte(input), &l); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != input {
+		t.Errorf("Expected links in their original order %s, got %s", input, b)
+	}
+}
+
+func TestAddArrayMarshalsSingleLinkAsArray(t *testing.T) {
+	l := Links{}.
+		Self("/orders").
+		AddArray("item", Link{Href: "/items/1"})
+
+	b, err := json.Marshal(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"_links":{"item":[{"href":"/items/1"}],"self":{"href":"/orders"}}}`
+	if string(b) != expected {
+		t.Errorf("Expected %s, got %s", expected, b)
+	}
+
+	if l.Items["item"][0].Equal(Link{Href: "/items/1"}) {
+		t.Error("Expected a link added with AddArray not to equal the same link added with Add, like ==")
+	}
+
+	plain := Links{}.Self("/orders").Merge(Links{}).Remove("other")
+	if !reflect.DeepEqual(plain, Links{Items: map[string]LinkSet{"self": {{Href: "/orders"}}}}) {
+		t.Errorf("Expected links without AddArray to be deeply equal to the same literal, got %#v", plain)
+	}
+}
+
+func TestAddAfterAddArrayMarshalsAsArray(t *testing.T) {
+	l := Links{}.
+		AddArray("item", Link{Href: "/items/0"}).
+		Add("item", Link{Href: "/items/1"}).
+		RemoveWhere("item", func(l Link) bool { return l.Href == "/items/0" })
+
+	b, err := json.Marshal(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"_links":{"item":[{"href":"/items/1"}]}}`
+	if string(b) != expected {
+		t.Errorf("Expected %s, got %s", expected, b)
+	}
+
+	b, err = json.Marshal(l.Remove("other").Merge(Links{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != expected {
+		t.Errorf("Expected Remove and Merge to keep the relation an array %s, got %s", expected, b)
+	}
+}
+
+func TestLinkMovedBetweenRelationsTakesItsNewRelationsSetting(t *testing.T) {
+	l := Links{}.AddArray("item", Link{Href: "/items/1"})
+	l = l.Add("latest", l.Items["item"][0])
+
+	other := Links{}.Add("item", l.Items["latest"][0])
+	other = other.AddArray("archived", other.Items["item"][0])
+
+	b, err := json.Marshal(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"_links":{"item":[{"href":"/items/1"}],"latest":{"href":"/items/1"}}}`
+	if string(b) != expected {
+		t.Errorf("Expected %s, got %s", expected, b)
+	}
+
+	b, err = json.Marshal(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `{"_links":{"archived":[{"href":"/items/1"}],"item":{"href":"/items/1"}}}`
+	if string(b) != expected {
+		t.Errorf("Expected %s, got %s", expected, b)
+	}
+}
+
+func TestValidateLinks(t *testing.T) {
+	l := Links{}.
+		Self("/orders").
+		Link("find", "/orders{?id}").
+		Link("broken", "http://exa mple.com/%zz").
+		Add("unterminated", Link{Href: "/orders{?id", Templated: true}).
+		Add("untemplated", Link{Href: "/orders", Templated: true}).
+		Add("empty", Link{})
+
+	errs := l.Validate()
+
+	expected := []string{
+		"Invalid 'broken' link (http://exa mple.com/%zz): href isn't a valid URL",
+		"Invalid 'empty' link (): href is empty",
+		"Invalid 'untemplated' link (/orders): link is templated but href has no template expressions",
+		"Invalid 'unterminated' link (/orders{?id): href isn't a valid URI template",
+	}
+
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), errs)
+	}
+
+	for i, err := range errs {
+		if !strings.HasPrefix(err.Error(), expected[i]) {
+			t.Errorf("Expected error %q, got %q", expected[i], err)
+		}
+	}
+
+	if errs := (Links{}).Self("/").Validate(); len(errs) != 0 {
+		t.Errorf("Expected valid links to have no errors, got %v", errs)
+	}
+}
+
+func TestUnmarshalSetsTemplated(t *testing.T) {
+	var res MyResource
+	err := json.Unmarshal([]byte(`{"_links":{
+    "flagged":{"href":"/orders{?id}","templated":true},
+    "unflagged":{"href":"/orders{?id}"},
+    "plain":{"href":"/orders"},
+    "many":[{"href":"/a/{id}"},{"href":"/b"}]
+  }}`), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !res.Items["flagged"][0].Templated {
+		t.Error("flagged should have Templated=true")
+	}
+
+	if !res.Items["unflagged"][0].Templated {
+		t.Error("unflagged should have Templated=true")
+	}
+
+	if res.Items["plain"][0].Templated {
+		t.Error("plain should have Templated=false")
+	}
+
+	if many := res.Items["many"]; len(many) != 2 || !many[0].Templated || many[1].Templated {
+		t.Errorf("Expected only the first of many to be templated, got %v", many)
+	}
+}
+
+func TestLinksCuries(t *testing.T) {
+	var res MyResource
+	err := json.Unmarshal([]byte(`{"_links":{
+    "curies":[
+      {"name":"ea","href":"http://example.com/docs/rels/{rel}","templated":true},
+      {"href":"http://example.com/unnamed"},
+      {"name":"acme","href":"http://acme.com/rels/{rel}","templated":true}
+    ],
+    "ea:orders":{"href":"/orders"}
+  }}`), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Curie{
+		{"ea", "http://example.com/docs/rels/{rel}"},
+		{"acme", "http://acme.com/rels/{rel}"},
+	}
+	if curies := res.Curies(); fmt.Sprint(curies) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, curies)
+	}
+
+	if curies := (Links{}).Curies(); len(curies) != 0 {
+		t.Errorf("Expected no curies, got %v", curies)
+	}
+}
@@ -2,12 +2,43 @@ package halgo
 
 import "encoding/json"
 
-// linkSet is represents a set of HAL links. Deserialisable from a single
+// LinkSet represents a set of HAL links. Deserialisable from a single
 // JSON hash, or a collection of links.
-type linkSet []Link
+type LinkSet []Link
 
-func (l linkSet) MarshalJSON() ([]byte, error) {
-	if len(l) == 1 {
+// Equal returns whether both sets contain the same links, regardless of
+// their order.
+func (l LinkSet) Equal(other LinkSet) bool {
+	if len(l) != len(other) {
+		return false
+	}
+
+	matched := make([]bool, len(other))
+
+outer:
+	for _, link := range l {
+		for i, o := range other {
+			if !matched[i] && link.Equal(o) {
+				matched[i] = true
+				continue outer
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// alwaysArray returns whether the set's relation was added with
+// Links.AddArray.
+func (l LinkSet) alwaysArray() bool {
+	return len(l) > 0 && l[0].alwaysArray
+}
+
+// MarshalJSON serialises a set of one link as a JSON object, unless its
+// relation was added with Links.AddArray, and any other set as an array.
+func (l LinkSet) MarshalJSON() ([]byte, error) {
+	if len(l) == 1 && !l[0].alwaysArray {
 		return json.Marshal(l[0])
 	}
 
@@ -17,7 +48,7 @@ func (l linkSet) MarshalJSON() ([]byte, error) {
 	return json.Marshal(other)
 }
 
-func (l *linkSet) UnmarshalJSON(d []byte) error {
+func (l *LinkSet) UnmarshalJSON(d []byte) error {
 	single := Link{}
 	err := json.Unmarshal(d, &single)
 	if err == nil {
@@ -0,0 +1,112 @@
+package halgo
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// MockHttpClient is a HttpClient for testing code which uses a navigator,
+// without starting a server. It responds to requests with canned
+// responses registered by URL, and records every request it receives so
+// tests can check which links were followed and what was sent.
+//
+//     client := &halgo.MockHttpClient{}
+//     client.Respond("http://api.example.com/", 200,
+//       `{ "_links": { "orders": { "href": "/orders" } } }`)
+//     client.Respond("http://api.example.com/orders/*", 200, `{}`)
+//
+//     nav := halgo.Navigator("http://api.example.com/").WithClient(client)
+//
+// Requests which don't match any registered response fail with an error.
+type MockHttpClient struct {
+	mu        sync.Mutex
+	responses []mockResponse
+	requests  []RecordedRequest
+}
+
+// RecordedRequest is a request received by a MockHttpClient, or saved in
+// the cassette of a RecordingHttpClient.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// mockResponse is a response registered with a MockHttpClient.
+type mockResponse struct {
+	pattern string
+	respond func(*http.Request) (*http.Response, error)
+}
+
+// Respond registers a response with the given status and body for
+// requests to URLs matching pattern, which is either a URL or a glob as
+// understood by path.Match. The response has a HAL Content-Type. When
+// several patterns match a request, the first registered is used.
+func (c *MockHttpClient) Respond(pattern string, status int, body string) {
+	c.RespondFunc(pattern, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			StatusCode:    status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/hal+json"}},
+			Body:          ioutil.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	})
+}
+
+// RespondFunc registers fn to respond to requests to URLs matching
+// pattern, for responses which need headers or depend on the request.
+func (c *MockHttpClient) RespondFunc(pattern string, fn func(*http.Request) (*http.Response, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.responses = append(c.responses, mockResponse{pattern, fn})
+}
+
+// Requests returns every request received so far, in the order they were
+// made.
+func (c *MockHttpClient) Requests() []RecordedRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]RecordedRequest{}, c.requests...)
+}
+
+// Do records req and responds with the first response registered for its
+// URL.
+func (c *MockHttpClient) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	url := req.URL.String()
+
+	c.mu.Lock()
+	c.requests = append(c.requests, RecordedRequest{req.Method, url, cloneHeader(req.Header), body})
+	responses := c.responses
+	c.mu.Unlock()
+
+	for _, res := range responses {
+		if matched, _ := path.Match(res.pattern, url); matched || res.pattern == url {
+			return res.respond(req)
+		}
+	}
+
+	return nil, fmt.Errorf("No mock response for %s %s", req.Method, url)
+}
@@ -0,0 +1,62 @@
+package halgo
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMockHttpClient(t *testing.T) {
+	client := &MockHttpClient{}
+	client.Respond("http://api.example.com/", 200, `{ "_links": { "orders": { "href": "/orders/1" } } }`)
+	client.Respond("http://api.example.com/orders/*", 201, `{}`)
+
+	nav := Navigator("http://api.example.com/").AddHeaders(map[string]string{"X-Test": "yes"})
+	nav.HttpClient = client
+
+	res, err := nav.Follow("orders").Post("text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 201 {
+		t.Errorf("Expected 201, got %d", res.StatusCode)
+	}
+
+	requests := client.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+
+	if r := requests[0]; r.Method != "GET" || r.URL != "http://api.example.com/" {
+		t.Errorf("Expected GET of the root, got %s %s", r.Method, r.URL)
+	}
+
+	r := requests[1]
+	if r.Method != "POST" || r.URL != "http://api.example.com/orders/1" {
+		t.Errorf("Expected POST to the order, got %s %s", r.Method, r.URL)
+	}
+	if r.Header.Get("X-Test") != "yes" {
+		t.Errorf("Expected X-Test header to be recorded, got %v", r.Header)
+	}
+	if string(r.Body) != "hello" {
+		t.Errorf("Expected body to be recorded, got %s", r.Body)
+	}
+}
+
+func TestMockHttpClientWithoutResponse(t *testing.T) {
+	client := &MockHttpClient{}
+	client.RespondFunc("http://api.example.com/*", func(req *http.Request) (*http.Response, error) {
+		t.Errorf("Expected no response for %s", req.URL)
+		return nil, nil
+	})
+
+	req, _ := http.NewRequest("DELETE", "http://other.example.com/", nil)
+	_, err := client.Do(req)
+	if err == nil || err.Error() != "No mock response for DELETE http://other.example.com/" {
+		t.Errorf("Expected an error for an unmatched request, got %v", err)
+	}
+
+	if len(client.Requests()) != 1 {
+		t.Errorf("Expected the unmatched request to be recorded")
+	}
+}
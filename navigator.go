@@ -1,13 +1,23 @@
 package halgo
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Navigator is a mechanism for navigating HAL-compliant REST APIs. You
@@ -52,12 +62,14 @@ import (
 // It's only when you add a call to Get, Post, PostForm, Patch, or
 // Unmarshal to the end will any requests be triggered.
 //
+// The uri must be an absolute URL. If it isn't, evaluating the navigator
+// returns an InvalidUrlError before any requests are made.
+//
 // By default a Navigator will use http.DefaultClient as its mechanism for
 // making HTTP requests. If you want to supply your own HttpClient, you
-// can assign to nav.HttpClient after creation.
+// can use WithClient.
 //
-//     nav := Navigator("http://api.example.com")
-//     nav.HttpClient = MyHttpClient{}
+//     nav := Navigator("http://api.example.com").WithClient(MyHttpClient{})
 //
 // Any Client you supply must implement halgo.HttpClient, which
 // http.Client does implicitly. By creating decorators for the HttpClient,
@@ -76,6 +88,29 @@ func Navigator(uri string) navigator {
 type relation struct {
 	rel    string
 	params P
+
+	// profile, if set, selects the link with the matching profile.
+	profile string
+
+	// lang, if set, selects the link with the matching hreflang.
+	lang string
+
+	// strict requires params to match the variables of the template.
+	strict bool
+
+	// url, if set, is followed instead of looking up a relation.
+	url string
+
+	// query is added to the query string of the link.
+	query url.Values
+
+	// embedded, if set, scopes the next relation to the links of the
+	// embedded resource with this relation.
+	embedded string
+
+	// anyOf, if set, are relations to try in order, following the first
+	// which exists.
+	anyOf []string
 }
 
 // navigator is the API navigator
@@ -90,6 +125,112 @@ type navigator struct {
 
 	// rootUri is where the navigation will begin from.
 	rootUri string
+
+	// relocated is set once rootUri has been moved on from the URI given
+	// to Navigator, such as to the next page by EachPage, so failing to
+	// fetch it isn't a RootFetchError.
+	relocated bool
+
+	// rootRequest marks the navigator making the request for the root
+	// document, whose transport failures are RootFetchErrors. It's set
+	// for each evaluation by begin and for the first fetch of resolve.
+	rootRequest bool
+
+	// header is added to every request made by the navigator, including
+	// the requests for each relation in the follow queue.
+	header http.Header
+
+	// tipHeader is sent only with the request to the tip of the follow
+	// queue, such as conditional headers and per-call headers, and takes
+	// precedence over header.
+	tipHeader http.Header
+
+	// accept are the media types requested, in order of preference. The
+	// defaultAccept media types are requested if it's nil, and no Accept
+	// header is sent if it's empty.
+	accept []string
+
+	// concurrency limits how many requests FollowParallel makes at once.
+	// There's no limit if it's zero.
+	concurrency int
+
+	// timeout bounds how long a single evaluation of the navigator may
+	// take, across every request it makes.
+	timeout time.Duration
+
+	// observer, if set, is called after every request the navigator
+	// makes.
+	observer Observer
+
+	// ctx is the context requests are made with, which is derived for
+	// each evaluation when there's a timeout.
+	ctx context.Context
+
+	// trace, if set, is called with each relation looked up while
+	// resolving the URL of the tip of the follow queue.
+	trace func(TraceStep)
+
+	// seed holds the links of documents which have already been fetched,
+	// which every evaluation of the navigator starts its linkCache with.
+	seed linkCache
+
+	// cacheTTLs are how long the documents reached by following each
+	// relation are kept in documents, with the root document under "".
+	cacheTTLs map[string]time.Duration
+
+	// documents holds the documents cached by CacheRelation, and is
+	// shared by every navigator derived from the one it was created for.
+	documents *documentCache
+
+	// emptyHrefIsSelf resolves empty hrefs to the document they're in.
+	emptyHrefIsSelf bool
+
+	// caseInsensitiveRels allows relations to be matched regardless of
+	// case when the document has no exact match.
+	caseInsensitiveRels bool
+
+	// checkStatus turns error responses into errors.
+	checkStatus bool
+
+	// maxDepth limits how many relations an evaluation may follow. There's
+	// no limit if it's zero.
+	maxDepth int
+
+	// endPagingOnCycle stops EachPage without an error when it reaches a
+	// page it has already visited.
+	endPagingOnCycle bool
+
+	// expander expands the hrefs of links. ExpandTemplate is used if it's
+	// nil.
+	expander TemplateExpander
+
+	// maxResponseSize limits how many bytes of a response body are read.
+	// There's no limit if it's zero.
+	maxResponseSize int64
+
+	// verifyDigest checks response bodies against their Content-Length,
+	// Content-MD5 and Digest headers.
+	verifyDigest bool
+
+	// useLinkHeader adds the links in the Link headers of documents to
+	// the links in their bodies.
+	useLinkHeader bool
+
+	// langFallback follows the first link of a relation when none match
+	// the language requested with FollowLang.
+	langFallback bool
+
+	// warnCurie, if set, is called when a compact relation is followed
+	// from a document which doesn't define its prefix.
+	warnCurie UndefinedCurieFunc
+
+	// decoder decodes response bodies. json.Unmarshal is used if it's
+	// nil.
+	decoder Decoder
+
+	// selector picks which link of a relation to follow. The first link
+	// is followed if it's nil.
+	selector LinkSelector
 }
 
 // Follow adds a relation to the follow queue of the navigator.
@@ -97,225 +238,2149 @@ func (n navigator) Follow(rel string) navigator {
 	return n.Followf(rel, nil)
 }
 
-// Followf adds a relation to the follow queue of the navigator, with a
-// set of parameters to expand on execution.
-func (n navigator) Followf(rel string, params P) navigator {
-	relations := append([]relation{}, n.path...)
-	relations = append(relations, relation{rel: rel, params: params})
+// Followf adds a relation to the follow queue of the navigator, with a
+// set of parameters to expand on execution. The parameters are copied, so
+// changing params afterwards doesn't affect the navigator.
+func (n navigator) Followf(rel string, params P) navigator {
+	relations := append([]relation{}, n.path...)
+	relations = append(relations, relation{rel: rel, params: params.clone()})
+
+	n.path = relations
+	return n
+}
+
+// FollowfStrict adds a relation to the follow queue of the navigator,
+// with a set of parameters to expand on execution. Unlike Followf, which
+// ignores parameters the URI template doesn't use and leaves out
+// variables which aren't given, a TemplateParamsError is returned on
+// execution unless params has exactly the variables of the template.
+// This catches misspelt parameter names.
+func (n navigator) FollowfStrict(rel string, params P) navigator {
+	relations := append([]relation{}, n.path...)
+	relations = append(relations, relation{rel: rel, params: params.clone(), strict: true})
+
+	n.path = relations
+	return n
+}
+
+// FollowProfile adds a relation to the follow queue of the navigator,
+// selecting the link with the given profile URI when the relation has
+// several links. A NoMatchingLinkError is returned on execution if none
+// of the links have the profile.
+func (n navigator) FollowProfile(rel, profile string) navigator {
+	relations := append([]relation{}, n.path...)
+	relations = append(relations, relation{rel: rel, profile: profile})
+
+	n.path = relations
+	return n
+}
+
+// WithMaxDepth returns a navigator which follows at most depth relations
+// in a single evaluation, returning a MaxDepthExceededError rather than
+// making any requests if the follow queue is longer. InEmbedded and
+// FollowURL don't count towards it. EachPage counts each "next" page it
+// follows too, which stops it paging forever through a cycle. There's no
+// limit by default.
+func (n navigator) WithMaxDepth(depth int) navigator {
+	n.maxDepth = depth
+	return n
+}
+
+// EndPagingOnCycle controls whether EachPage stops cleanly when a "next"
+// link points to a page it has already visited, as some servers do on the
+// last page. Off by default, where EachPage returns a PageCycleError.
+func (n navigator) EndPagingOnCycle(enabled bool) navigator {
+	n.endPagingOnCycle = enabled
+	return n
+}
+
+// WithTemplateExpander returns a navigator which expands the hrefs of the
+// links it follows with expand, for APIs which use a templating scheme
+// other than RFC 6570. Links are expanded with ExpandTemplate by default.
+//
+//     Navigator("http://api.example.com").
+//       WithTemplateExpander(func(href string, params halgo.P) (string, error) {
+//         return strings.Replace(href, "{id}", fmt.Sprint(params["id"]), -1), nil
+//       })
+func (n navigator) WithTemplateExpander(expand TemplateExpander) navigator {
+	n.expander = expand
+	return n
+}
+
+// UseLinkHeader controls whether links in the RFC 8288 Link headers of
+// responses are followed as well as the links in their bodies, for APIs
+// which advertise some or all of their relations in headers. Header links
+// are added after the body's links for the same relation. Off by default.
+//
+//     Link: </orders?page=2>; rel="next"
+func (n navigator) UseLinkHeader(enabled bool) navigator {
+	n.useLinkHeader = enabled
+	return n
+}
+
+// WithMaxResponseSize returns a navigator which reads at most max bytes of
+// a response body, returning a ResponseTooLargeError for longer bodies
+// rather than exhausting memory. This covers the documents fetched for
+// each relation in the follow queue, which should be small, and the
+// bodies read by GetBody, Unmarshal, UnmarshalResponse, GetResource,
+// Fetch, EachPage and FromResponse. Bodies returned unread, such as by
+// Get, aren't limited.
+//
+//     Navigator("http://api.example.com").
+//       WithMaxResponseSize(1 << 20)
+func (n navigator) WithMaxResponseSize(max int64) navigator {
+	n.maxResponseSize = max
+	return n
+}
+
+// VerifyDigest controls whether the bodies of responses the navigator
+// reads are checked against their Content-Length, Content-MD5 and RFC
+// 3230 Digest headers, returning a DigestMismatchError when they don't
+// match. This covers the documents fetched for each relation in the
+// follow queue and the bodies read by GetBody, Unmarshal,
+// UnmarshalResponse, GetResource, Fetch and EachPage. Responses without
+// these headers aren't checked. Off by default.
+//
+//     Digest: SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=
+func (n navigator) VerifyDigest(enabled bool) navigator {
+	n.verifyDigest = enabled
+	return n
+}
+
+// FollowURL adds a URL to the follow queue of the navigator, which is
+// moved to without looking up a relation or fetching the current
+// document. A relative url is resolved against the URL the queue had
+// reached. Relations followed afterwards are looked up in the document at
+// url.
+//
+//     Navigator("http://api.example.com").
+//       FollowURL("/products/12").
+//       Follow("reviews").
+//       Get()
+func (n navigator) FollowURL(url string) navigator {
+	relations := append([]relation{}, n.path...)
+	relations = append(relations, relation{url: url})
+
+	n.path = relations
+	return n
+}
+
+// FollowQuery adds a relation to the follow queue of the navigator, and
+// adds q to the query string of its link. It's for APIs which accept query
+// parameters their links don't advertise with a URI template. Parameters
+// already in the link's query string are kept, with any of the same name
+// as parameters in q replaced.
+//
+//     Navigator("http://api.example.com").
+//       FollowQuery("products", url.Values{"colour": {"red"}}).
+//       Get()
+func (n navigator) FollowQuery(rel string, q url.Values) navigator {
+	query := url.Values{}
+	for k, v := range q {
+		query[k] = append([]string{}, v...)
+	}
+
+	relations := append([]relation{}, n.path...)
+	relations = append(relations, relation{rel: rel, query: query})
+
+	n.path = relations
+	return n
+}
+
+// FollowAny adds to the follow queue of the navigator the first of rels
+// which the document has, for APIs which are moving from one relation name
+// to another. An AnyLinkNotFoundError listing every one of rels is
+// returned on execution if the document has none of them.
+//
+//     Navigator("http://api.example.com").
+//       FollowAny("ea:orders", "orders").
+//       Get()
+func (n navigator) FollowAny(rels ...string) navigator {
+	first := ""
+	if len(rels) > 0 {
+		first = rels[0]
+	}
+
+	relations := append([]relation{}, n.path...)
+	relations = append(relations, relation{rel: first, anyOf: append([]string{}, rels...)})
+
+	n.path = relations
+	return n
+}
+
+// InEmbedded scopes the next relation in the follow queue to the links of
+// the resource embedded with rel in the current document, rather than the
+// links of the document itself. It saves fetching the embedded resource
+// when it already has the links needed. If several resources are embedded
+// with rel the first is used. An EmbeddedNotFoundError is returned on
+// execution if there's no resource embedded with rel.
+//
+//     Navigator("http://api.example.com").
+//       Follow("order").
+//       InEmbedded("customer").
+//       Follow("address").
+//       Get()
+//
+// InEmbedded has no effect at the end of the queue, where the navigator
+// is still at the containing document.
+func (n navigator) InEmbedded(rel string) navigator {
+	relations := append([]relation{}, n.path...)
+	relations = append(relations, relation{embedded: rel})
+
+	n.path = relations
+	return n
+}
+
+// FollowLang adds a relation to the follow queue of the navigator,
+// selecting the link whose hreflang is lang when the relation has links in
+// several languages. Languages are compared ignoring case. A
+// NoMatchingLinkError is returned on execution if none of the links are
+// in the language, unless LangFallback is enabled.
+//
+//     Navigator("http://api.example.com").
+//       FollowLang("help", "de").
+//       Get()
+func (n navigator) FollowLang(rel, lang string) navigator {
+	relations := append([]relation{}, n.path...)
+	relations = append(relations, relation{rel: rel, lang: lang})
+
+	n.path = relations
+	return n
+}
+
+// LangFallback controls whether a relation followed with FollowLang which
+// has no link in the requested language falls back to its first link.
+// Off by default, where it's a NoMatchingLinkError.
+func (n navigator) LangFallback(enabled bool) navigator {
+	n.langFallback = enabled
+	return n
+}
+
+// CaseInsensitiveRels controls whether relations are matched ignoring
+// case, for APIs which aren't consistent about their relation names. An
+// exact match is always preferred; if there's no exact match and more
+// than one relation differs only by case, an AmbiguousRelationError is
+// returned. Off by default, as relations are case-sensitive per the spec.
+//
+//     Navigator("http://api.example.com").
+//       CaseInsensitiveRels(true).
+//       Follow("Next")
+func (n navigator) CaseInsensitiveRels(enabled bool) navigator {
+	n.caseInsensitiveRels = enabled
+	return n
+}
+
+// WithClient returns a navigator which makes its requests with c instead
+// of the HttpClient it had, such as a CachingHttpClient. It's the same as
+// assigning to HttpClient, but can be part of a chain.
+//
+//     Navigator("http://api.example.com").
+//       WithClient(&halgo.CachingHttpClient{HttpClient: http.DefaultClient}).
+//       Follow("products")
+func (n navigator) WithClient(c HttpClient) navigator {
+	n.HttpClient = c
+	return n
+}
+
+// WithBasicAuth returns a navigator which uses HTTP basic authentication
+// with the given credentials for every request it makes.
+func (n navigator) WithBasicAuth(username, password string) navigator {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return n.AddHeaders(map[string]string{"Authorization": "Basic " + credentials})
+}
+
+// WithBearerToken returns a navigator which sends the given bearer token
+// in the Authorization header of every request it makes.
+func (n navigator) WithBearerToken(token string) navigator {
+	return n.AddHeaders(map[string]string{"Authorization": "Bearer " + token})
+}
+
+// WithUserAgent returns a navigator which sends ua in the User-Agent
+// header of every request it makes, including the request for each
+// relation in the follow queue.
+//
+//     Navigator("http://api.example.com").
+//       WithUserAgent("inventory-sync/1.2 (ops@example.com)")
+func (n navigator) WithUserAgent(ua string) navigator {
+	return n.AddHeaders(map[string]string{"User-Agent": ua})
+}
+
+// AddHeaders returns a navigator which sends the given headers with every
+// request it makes, including the request for each relation in the follow
+// queue. The headers are merged with any added previously, replacing the
+// values of headers with the same name.
+//
+// Headers added to a navigator take precedence over the ones it would
+// otherwise send, such as Accept (including any set with Accept) and the
+// Content-Type of a Post.
+//
+//     Navigator("http://api.example.com").
+//       AddHeaders(map[string]string{"X-Api-Version": "2"})
+func (n navigator) AddHeaders(headers map[string]string) navigator {
+	h := http.Header{}
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+
+	return n.WithHeaders(h)
+}
+
+// WithHeaders is like AddHeaders, but takes an http.Header so headers can
+// have several values. The values of each header in h replace any the
+// navigator already had for it.
+//
+//     Navigator("http://api.example.com").
+//       WithHeaders(http.Header{"Accept-Language": {"en", "de;q=0.5"}})
+func (n navigator) WithHeaders(h http.Header) navigator {
+	header := cloneHeader(n.header)
+	for k, v := range h {
+		header.Del(k)
+		for _, value := range v {
+			header.Add(k, value)
+		}
+	}
+
+	n.header = header
+	return n
+}
+
+// IfMatch returns a navigator which sends etag in an If-Match header with
+// the request to the tip of the follow queue, but not with the requests
+// for each relation in the queue. The server only acts on the request if
+// the resource still has that ETag, so changes made by others since it
+// was read aren't overwritten. etag should be as it was given in the
+// ETag header, including quotes. SubmitForm, FollowForm and Update send
+// it with their write only, not with the GET of the resource before it.
+//
+// With CheckStatus, a PreconditionFailedError is returned if the
+// resource has changed, so the caller can read it again and retry.
+//
+//     _, err := Navigator("http://api.example.com").
+//       CheckStatus(true).
+//       Follow("order").
+//       IfMatch(etag).
+//       PutJSON(order)
+//
+//     var changed halgo.PreconditionFailedError
+//     if errors.As(err, &changed) {
+//       // read, modify and write again
+//     }
+func (n navigator) IfMatch(etag string) navigator {
+	return n.withTipHeaders(http.Header{"If-Match": {etag}})
+}
+
+// IfNoneMatch returns a navigator which sends etag in an If-None-Match
+// header with the request to the tip of the follow queue, but not with
+// the requests for each relation in the queue. An etag of "*" makes a
+// PUT only create a resource, never replace one. Failures are returned as
+// for IfMatch, and it applies to the same requests.
+func (n navigator) IfNoneMatch(etag string) navigator {
+	return n.withTipHeaders(http.Header{"If-None-Match": {etag}})
+}
+
+// withTipHeaders returns a navigator which sends headers with the request
+// to the tip of the follow queue, but not with the requests for each
+// relation in the queue. The values of each header replace any the
+// navigator already had for it.
+func (n navigator) withTipHeaders(headers ...http.Header) navigator {
+	tipHeader := cloneHeader(n.tipHeader)
+	for _, h := range headers {
+		for k, v := range h {
+			tipHeader.Del(k)
+			for _, value := range v {
+				tipHeader.Add(k, value)
+			}
+		}
+	}
+
+	n.tipHeader = tipHeader
+	return n
+}
+
+// Accept returns a navigator which requests the given media types in the
+// Accept header of every request, instead of the default of
+// application/hal+json and application/json. Media types are listed in
+// the order given, which is their order of preference.
+//
+//     Navigator("http://api.example.com").
+//       Accept("application/vnd.example+json", "application/hal+json")
+func (n navigator) Accept(mediaTypes ...string) navigator {
+	n.accept = append([]string{}, mediaTypes...)
+	return n
+}
+
+// NoDefaultAccept returns a navigator which doesn't send an Accept header
+// with its requests, for servers which reject the default of
+// application/hal+json and application/json. An Accept header set with
+// Accept, AcceptHalForms or AddHeaders is still sent.
+func (n navigator) NoDefaultAccept() navigator {
+	n.accept = []string{}
+	return n
+}
+
+// AcceptHalForms returns a navigator which prefers HAL-FORMS responses,
+// by adding application/prs.hal-forms+json to the front of the Accept
+// header of every request. Servers which serve both HAL and HAL-FORMS
+// will then include the _templates of resources.
+func (n navigator) AcceptHalForms() navigator {
+	accept := n.accept
+	if accept == nil {
+		accept = defaultAccept
+	}
+
+	n.accept = append([]string{halFormsMediaType}, accept...)
+	return n
+}
+
+// WithConcurrency returns a navigator which makes at most limit requests
+// at once when following relations in parallel with FollowParallel or
+// FollowAll. By default there's no limit.
+func (n navigator) WithConcurrency(limit int) navigator {
+	n.concurrency = limit
+	return n
+}
+
+// WithTimeout returns a navigator which limits how long each evaluation
+// may take, including the request for every relation in the follow
+// queue and the final request, independent of any timeout on the
+// HttpClient. The body of a returned response must be read before the
+// timeout expires.
+//
+// If the timeout expires while following the queue a FollowTimeoutError
+// is returned, which identifies the relation being followed.
+func (n navigator) WithTimeout(d time.Duration) navigator {
+	n.timeout = d
+	return n
+}
+
+// Observer is called after every request a navigator makes, with the
+// request, the response or error the HttpClient returned, and how long the
+// round trip took.
+type Observer func(req *http.Request, res *http.Response, err error, elapsed time.Duration)
+
+// WithObserver returns a navigator which calls observer after every
+// request it makes, including the request for each relation in the follow
+// queue. It's a lighter alternative to decorating the HttpClient for
+// collecting metrics or tracing.
+//
+//     Navigator("http://api.example.com").
+//       WithObserver(func(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+//         log.Printf("%s %s took %s", req.Method, req.URL, elapsed)
+//       })
+func (n navigator) WithObserver(observer Observer) navigator {
+	n.observer = observer
+	return n
+}
+
+// EmptyHrefIsSelf controls whether a link with an empty href refers to
+// the document it's in, which is how RFC 3986 resolves an empty
+// reference. Off by default, where an empty href is an InvalidUrlError.
+func (n navigator) EmptyHrefIsSelf(enabled bool) navigator {
+	n.emptyHrefIsSelf = enabled
+	return n
+}
+
+// RequestCounter counts the requests made by navigators it observes,
+// which is useful for spotting chains which make more requests than they
+// need to. Its Observe method is an Observer.
+//
+//     var counter halgo.RequestCounter
+//     Navigator("http://api.example.com").
+//       WithObserver(counter.Observe).
+//       Follow("products").
+//       Get()
+//     counter.Count() // 2: the root, then products
+type RequestCounter struct {
+	count int64
+}
+
+// Observe counts a request. It's safe to call concurrently.
+func (c *RequestCounter) Observe(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+	atomic.AddInt64(&c.count, 1)
+}
+
+// Count returns how many requests have been observed since the counter
+// was created or last reset.
+func (c *RequestCounter) Count() int {
+	return int(atomic.LoadInt64(&c.count))
+}
+
+// Reset sets the count back to zero, such as before the next terminal
+// call of a navigator.
+func (c *RequestCounter) Reset() {
+	atomic.StoreInt64(&c.count, 0)
+}
+
+// LinkSelector picks which link to follow from the links of a relation,
+// which there's always at least one of.
+type LinkSelector func(rel string, set LinkSet) (Link, error)
+
+// WithLinkSelector returns a navigator which uses selector to pick which
+// link to follow when a relation has more than one. By default the first
+// link is followed. Relations followed with FollowProfile are still
+// selected by their profile.
+//
+//     Navigator("http://api.example.com").
+//       WithLinkSelector(func(rel string, set halgo.LinkSet) (halgo.Link, error) {
+//         return set[len(set)-1], nil
+//       })
+func (n navigator) WithLinkSelector(selector LinkSelector) navigator {
+	n.selector = selector
+	return n
+}
+
+// CheckStatus controls whether responses with a 4xx or 5xx status are
+// returned as errors, rather than as responses for the caller to inspect.
+// This applies to every request the navigator makes, including the request
+// for each relation in the follow queue.
+//
+// The error is a HTTPError, or a ProblemDetails if the response body is an
+// RFC 7807 application/problem+json document.
+//
+//     _, err := Navigator("http://api.example.com").
+//       CheckStatus(true).
+//       Follow("products").
+//       Get()
+//
+//     var problem halgo.ProblemDetails
+//     if errors.As(err, &problem) {
+//       log.Println(problem.Detail)
+//     }
+func (n navigator) CheckStatus(enabled bool) navigator {
+	n.checkStatus = enabled
+	return n
+}
+
+// CacheRelation returns a navigator which keeps the document reached by
+// following rel for ttl, rather than fetching it again every time the
+// navigator, or any navigator derived from it, is evaluated. It's for
+// intermediate documents which are traversed often but rarely change,
+// while relations which aren't cached are always fetched. The root
+// document is cached with the relation "", and a document reached with
+// FollowAny is cached for the alternative which was followed. Documents
+// are cached by URL, so a relation which leads somewhere else is fetched
+// from there, and by the headers the navigator sends, so a navigator with
+// other credentials fetches its own.
+//
+//     api := Navigator("http://api.example.com").
+//       CacheRelation("", time.Hour).
+//       CacheRelation("orders", 10*time.Minute)
+//
+//     api.Follow("orders").Follow("latest").Get()
+//
+// Only the documents the navigator looks up links in are cached. The
+// response of a terminal request such as Get is never cached.
+func (n navigator) CacheRelation(rel string, ttl time.Duration) navigator {
+	ttls := make(map[string]time.Duration, len(n.cacheTTLs)+1)
+	for k, v := range n.cacheTTLs {
+		ttls[k] = v
+	}
+	ttls[rel] = ttl
+
+	n.cacheTTLs = ttls
+	if n.documents == nil {
+		n.documents = &documentCache{}
+	}
+	return n
+}
+
+// Decoder decodes the JSON in r into v, like json.Decoder.Decode.
+type Decoder func(r io.Reader, v interface{}) error
+
+// WithDecoder returns a navigator which decodes response bodies with
+// decode instead of encoding/json, such as to reject unknown fields or
+// to use a faster JSON library. It's used by Unmarshal,
+// UnmarshalResponse, GetResource, Update, Fetch and Response.Decode, and
+// for the documents links are looked up in. Only the _links and _embedded
+// of those documents are read, so their other fields are never unknown.
+//
+//     Navigator("http://api.example.com").
+//       WithDecoder(func(r io.Reader, v interface{}) error {
+//         dec := json.NewDecoder(r)
+//         dec.DisallowUnknownFields()
+//         return dec.Decode(v)
+//       })
+func (n navigator) WithDecoder(decode Decoder) navigator {
+	n.decoder = decode
+	return n
+}
+
+// decode decodes body into v with the navigator's Decoder.
+func (n navigator) decode(body []byte, v interface{}) error {
+	if n.decoder == nil {
+		return json.Unmarshal(body, v)
+	}
+
+	return n.decoder(bytes.NewReader(body), v)
+}
+
+// UndefinedCurieFunc is called when a compact relation is followed from
+// the document at url, but the document's "curies" don't define the
+// relation's prefix.
+type UndefinedCurieFunc func(rel, url string)
+
+// WarnUndefinedCuries returns a navigator which calls warn whenever it
+// follows a compact relation, such as "ea:orders", from a document whose
+// "curies" don't define the relation's prefix. This breaks the HAL spec,
+// but the relation is still followed. Off by default.
+//
+//     Navigator("http://api.example.com").
+//       WarnUndefinedCuries(func(rel, url string) {
+//         log.Printf("%s has no curie for %s", url, rel)
+//       })
+func (n navigator) WarnUndefinedCuries(warn UndefinedCurieFunc) navigator {
+	n.warnCurie = warn
+	return n
+}
+
+// Location follows the Location header from a response.  It makes the URI
+// absolute, if necessary.
+func (n navigator) Location(resp *http.Response) (navigator, error) {
+	_, exists := resp.Header["Location"]
+	if !exists {
+		return n, fmt.Errorf("Response didn't contain a Location header")
+	}
+	loc := resp.Header.Get("Location")
+	lurl, err := makeAbsoluteIfNecessary(loc, n.rootUri)
+	if err != nil {
+		return n, err
+	}
+	n.path = []relation{}
+	n.rootUri = lurl
+	n.relocated = true
+	return n, nil
+}
+
+// linkCache holds the links of the documents fetched while evaluating a
+// navigator, so each document is only requested once per evaluation. A
+// linkCache must never outlive the evaluation it was made for, otherwise
+// it'd serve stale links. Documents are keyed by the URL they were
+// requested with.
+type linkCache map[string]document
+
+// documentCache holds documents across evaluations of a navigator until
+// they expire. It's safe to use from multiple goroutines.
+type documentCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedDocument
+}
+
+// cachedDocument is a document in a documentCache.
+type cachedDocument struct {
+	doc     document
+	expires time.Time
+}
+
+// get returns the document cached for uri, if it hasn't expired.
+func (c *documentCache) get(uri string) (document, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[uri]
+	if !ok || !time.Now().Before(entry.expires) {
+		return document{}, false
+	}
+
+	return entry.doc, true
+}
+
+// set caches doc for uri until ttl has passed.
+func (c *documentCache) set(uri string, doc document, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]cachedDocument)
+	}
+	c.entries[uri] = cachedDocument{doc, time.Now().Add(ttl)}
+}
+
+// document is the links of a fetched document, along with the URL it was
+// fetched from once any redirects were followed. Relative links in the
+// document are resolved against that URL.
+type document struct {
+	url         string
+	contentType string
+	links       Links
+	embedded    map[string]json.RawMessage
+
+	// body is the raw document, which is only kept while tracing.
+	body []byte
+}
+
+// halDocument is the parts of a HAL document the navigator reads.
+type halDocument struct {
+	Links
+	Embedded map[string]json.RawMessage `json:"_embedded,omitempty"`
+}
+
+// UnmarshalJSON reads the parts of a document the navigator needs,
+// ignoring the rest even when decoded with a Decoder which disallows
+// unknown fields.
+func (d *halDocument) UnmarshalJSON(b []byte) error {
+	type plain halDocument
+	return json.Unmarshal(b, (*plain)(d))
+}
+
+// embeddedDocument returns the resource embedded in the document with
+// rel, or the first of them if there are several. Relative links in the
+// resource are resolved against the document's URL.
+func (d document) embeddedDocument(rel string) (document, error) {
+	raw, ok := d.embedded[rel]
+	if !ok {
+		return document{}, EmbeddedNotFoundError{rel, d.url}
+	}
+
+	var resource halDocument
+	if err := json.Unmarshal(raw, &resource); err != nil {
+		var resources []halDocument
+		if err := json.Unmarshal(raw, &resources); err != nil {
+			return document{}, fmt.Errorf("Unable to unmarshal embedded '%s': %v", rel, err)
+		}
+		if len(resources) == 0 {
+			return document{}, EmbeddedNotFoundError{rel, d.url}
+		}
+		resource = resources[0]
+	}
+
+	return document{d.url, d.contentType, resource.Links, resource.Embedded, raw}, nil
+}
+
+// url returns the URL of the tip of the follow queue. Will follow the
+// usual pattern of requests.
+func (n navigator) url() (string, error) {
+	return n.urlWith(n.newLinkCache())
+}
+
+// urlWith returns the URL of the tip of the follow queue, using cache to
+// avoid requesting any document more than once.
+func (n navigator) urlWith(cache linkCache) (string, error) {
+	url, _, err := n.resolve(cache)
+	return url, err
+}
+
+// depth returns how many relations the follow queue looks up. InEmbedded
+// and FollowURL entries don't follow a relation, so they aren't counted.
+func (n navigator) depth() int {
+	depth := 0
+	for _, link := range n.path {
+		if link.url == "" && link.embedded == "" {
+			depth++
+		}
+	}
+	return depth
+}
+
+// resolve returns the URL of the tip of the follow queue, like urlWith,
+// along with how long the document there may be cached for.
+func (n navigator) resolve(cache linkCache) (string, time.Duration, error) {
+	if n.maxDepth > 0 && n.depth() > n.maxDepth {
+		return "", 0, MaxDepthExceededError{n.maxDepth}
+	}
+
+	if !isAbsoluteURL(n.rootUri) {
+		return "", 0, InvalidUrlError{n.rootUri}
+	}
+
+	url := n.rootUri
+
+	// root is whether url is still the root document given to Navigator.
+	root := !n.relocated
+
+	// ttl is how long the document at url may be cached for.
+	ttl := n.cacheTTLs[""]
+
+	// scope is the embedded resource the next relation is looked up in,
+	// if any.
+	var scope *document
+
+	for _, link := range n.path {
+		first := root
+		root = false
+
+		if link.url != "" {
+			next, err := makeAbsoluteIfNecessary(link.url, url)
+			if err != nil {
+				return "", 0, fmt.Errorf("Error making url absolute: %v", err)
+			}
+			url = next
+			ttl = 0
+			scope = nil
+			continue
+		}
+
+		var doc document
+		var err error
+		if scope != nil {
+			doc, scope = *scope, nil
+		} else {
+			fetch := n
+			fetch.rootRequest = first
+			doc, err = fetch.getCachedLinks(cache, url, ttl)
+		}
+		if err != nil && n.ctx != nil && n.ctx.Err() == context.DeadlineExceeded {
+			return "", 0, FollowTimeoutError{link.rel, url, err}
+		}
+		if _, ok := err.(RootFetchError); ok {
+			return "", 0, err
+		}
+		if err != nil {
+			return "", 0, fmt.Errorf("Error getting links (%s, %v): %w", url, doc.links, err)
+		}
+
+		if link.embedded != "" {
+			embedded, err := doc.embeddedDocument(link.embedded)
+			if err != nil {
+				return "", 0, err
+			}
+			scope = &embedded
+			continue
+		}
+
+		if n.trace != nil {
+			n.trace(TraceStep{link.rel, doc.url, truncateBytes(doc.body, maxTraceBodyLength)})
+		}
+
+		if doc.links.Items == nil {
+			return "", 0, NoLinksError{doc.url, doc.contentType}
+		}
+
+		url, err = n.href(doc.url, doc.links, link)
+		if err != nil {
+			return "", 0, err
+		}
+		ttl = n.cacheTTLs[n.requestedRel(doc.links, link)]
+	}
+
+	return url, ttl, nil
+}
+
+// newLinkCache creates a linkCache for a single evaluation of the
+// navigator, containing any links the navigator was seeded with.
+func (n navigator) newLinkCache() linkCache {
+	cache := linkCache{}
+	for uri, doc := range n.seed {
+		cache[uri] = doc
+	}
+	return cache
+}
+
+// getCachedLinks returns the document at uri from the cache, requesting
+// the document only if it hasn't been already. If ttl is positive the
+// document is also looked up in, and added to, the navigator's
+// documentCache.
+func (n navigator) getCachedLinks(cache linkCache, uri string, ttl time.Duration) (document, error) {
+	if doc, ok := cache[uri]; ok {
+		return doc, nil
+	}
+
+	cacheable := ttl > 0 && n.documents != nil
+	if cacheable {
+		if doc, ok := n.documents.get(n.cacheKey(uri)); ok {
+			cache[uri] = doc
+			return doc, nil
+		}
+	}
+
+	doc, err := n.getLinks(uri)
+	if err != nil {
+		return doc, err
+	}
+
+	cache[uri] = doc
+	if cacheable {
+		// the body is only for tracing this evaluation, so it isn't kept
+		// for the evaluations which share the cache
+		shared := doc
+		shared.body = nil
+		n.documents.set(n.cacheKey(uri), shared, ttl)
+	}
+	return doc, nil
+}
+
+// cacheKey returns the key the document at uri is kept under in the
+// documentCache. The headers the navigator sends are part of the key, so
+// a navigator with other credentials, or any other header a server could
+// vary its response on, never reads a document fetched by another.
+func (n navigator) cacheKey(uri string) string {
+	var key strings.Builder
+	key.WriteString(uri)
+
+	if n.accept != nil {
+		fmt.Fprintf(&key, "\n%q", n.accept)
+	}
+
+	names := make([]string, 0, len(n.header))
+	for name := range n.header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(&key, "\n%s: %q", name, n.header[name])
+	}
+
+	return key.String()
+}
+
+// href finds the link for a relation in a links collection and returns
+// its expanded, absolute URL. base is the URL of the document the links
+// came from, which relative links are resolved against.
+func (n navigator) href(base string, links Links, link relation) (string, error) {
+	rel, err := n.findAnyRel(links, link)
+	if err != nil {
+		return "", err
+	}
+
+	if n.warnCurie != nil {
+		if prefix, ok := curiePrefix(rel); ok && !links.definesCurie(prefix) {
+			n.warnCurie(rel, base)
+		}
+	}
+
+	selected, err := n.selectLink(links, rel, link)
+	if err != nil {
+		return "", err
+	}
+
+	if link.strict {
+		if err := checkTemplateParams(rel, selected.Href, link.params); err != nil {
+			return "", err
+		}
+	}
+
+	expand := n.expander
+	if expand == nil {
+		expand = ExpandTemplate
+	}
+
+	url, err := expand(selected.Href, link.params)
+	if err != nil {
+		return "", fmt.Errorf("Error getting url (%v, %v): %v", link.rel, link.params, err)
+	}
+
+	if url == "" && n.emptyHrefIsSelf {
+		url = base
+	}
+
+	if url == "" {
+		return "", InvalidUrlError{url}
+	}
+
+	url, err = makeAbsoluteIfNecessary(url, base)
+	if err != nil {
+		return "", fmt.Errorf("Error making url absolute: %v", err)
+	}
+
+	if len(link.query) > 0 {
+		return addQuery(url, link.query)
+	}
+
+	return url, nil
+}
+
+// addQuery adds the parameters of q to the query string of href,
+// replacing any with the same name.
+func addQuery(href string, q url.Values) (string, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	for k, v := range q {
+		query[k] = v
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// selectLink picks which of the links of rel should be followed.
+func (n navigator) selectLink(links Links, rel string, link relation) (Link, error) {
+	set := links.Items[rel]
+	if len(set) == 0 {
+		return Link{}, EmptyLinkSetError{rel}
+	}
+
+	if link.profile != "" {
+		selected, ok := links.find(rel, func(l Link) bool { return l.Profile == link.profile })
+		if !ok {
+			return Link{}, NoMatchingLinkError{rel, fmt.Sprintf("profile '%s'", link.profile)}
+		}
+		return selected, nil
+	}
+
+	if link.lang != "" {
+		selected, ok := links.find(rel, func(l Link) bool { return strings.EqualFold(l.HrefLang, link.lang) })
+		if !ok && !n.langFallback {
+			return Link{}, NoMatchingLinkError{rel, fmt.Sprintf("hreflang '%s'", link.lang)}
+		}
+		if ok {
+			return selected, nil
+		}
+	}
+
+	if n.selector != nil {
+		return n.selector(rel, set)
+	}
+
+	return firstLink(rel, set)
+}
+
+// firstLink is the default LinkSelector, which picks the first link of a
+// relation.
+func firstLink(rel string, set LinkSet) (Link, error) {
+	return set[0], nil
+}
+
+// checkTemplateParams returns a TemplateParamsError unless params has
+// exactly the variables of the URI template href.
+func checkTemplateParams(rel, href string, params P) error {
+	names, err := templateNames(href)
+	if err != nil {
+		return err
+	}
+
+	unknown := []string{}
+	for name := range params {
+		if !names[name] {
+			unknown = append(unknown, name)
+		}
+	}
+
+	missing := []string{}
+	for name := range names {
+		if _, ok := params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(unknown) == 0 && len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	sort.Strings(missing)
+	return TemplateParamsError{rel, unknown, missing}
+}
+
+// findAnyRel returns the name of the relation in links to follow for
+// link, which is the first of its alternatives which exists if it has
+// any.
+func (n navigator) findAnyRel(links Links, link relation) (string, error) {
+	if len(link.anyOf) == 0 {
+		return n.findRel(links, link.rel)
+	}
+
+	for _, alternative := range link.anyOf {
+		rel, err := n.findRel(links, alternative)
+		if _, ok := err.(LinkNotFoundError); ok {
+			continue
+		}
+		return rel, err
+	}
+
+	return "", AnyLinkNotFoundError{link.anyOf, links.Items}
+}
+
+// requestedRel returns the relation the caller asked for which was found
+// in links for link: the first of its alternatives which exists if it has
+// any, otherwise its relation as given, whatever its case in links.
+func (n navigator) requestedRel(links Links, link relation) string {
+	for _, alternative := range link.anyOf {
+		if _, err := n.findRel(links, alternative); err == nil {
+			return alternative
+		}
+	}
+
+	return link.rel
+}
+
+// findRel returns the name of the relation in links which matches rel,
+// taking into account whether relations are case-insensitive.
+func (n navigator) findRel(links Links, rel string) (string, error) {
+	if !n.caseInsensitiveRels {
+		if _, ok := links.Items[rel]; !ok {
+			return "", LinkNotFoundError{rel, links.Items}
+		}
+		return rel, nil
+	}
+
+	return links.foldRel(rel)
+}
+
+// isAbsoluteURL returns whether uri is a well-formed absolute URL, which
+// a navigator can start from.
+func isAbsoluteURL(uri string) bool {
+	u, err := url.Parse(uri)
+	return err == nil && u.IsAbs() && u.Host != ""
+}
+
+// makeAbsoluteIfNecessary takes the current url and the url of the
+// document it appeared in, and will resolve the current URL against the
+// base as described in RFC 3986 if current isn't already absolute. IRIs
+// are converted to URIs, by percent-encoding any non-ASCII characters in
+// the path and query.
+func makeAbsoluteIfNecessary(current, base string) (string, error) {
+	currentUri, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+
+	if !currentUri.IsAbs() {
+		baseUri, err := url.Parse(base)
+		if err != nil {
+			return "", err
+		}
+
+		currentUri = baseUri.ResolveReference(currentUri)
+	}
+
+	// String percent-encodes the path, but leaves the query as it is
+	currentUri.RawQuery = escapeNonASCII(currentUri.RawQuery)
+	return currentUri.String(), nil
+}
+
+// escapeNonASCII percent-encodes the UTF-8 bytes of any non-ASCII
+// characters in s, leaving everything else untouched.
+func escapeNonASCII(s string) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= 0x80 {
+			fmt.Fprintf(&buf, "%%%02X", c)
+		} else {
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+// Get performs a GET request on the tip of the follow queue.
+//
+// When a navigator is evaluated it will first request the root, then
+// request each relation on the queue until it reaches the tip. Once the
+// tip is reached it will defer to the calling method. In the case of GET
+// the last request will just be returned. For Post it will issue a post
+// to the URL of the last relation. Any error along the way will terminate
+// the walk and return immediately.
+func (n navigator) Get() (*http.Response, error) {
+	return n.send("GET", "", nil)
+}
+
+// GetWithBody performs a GET request on the tip of the follow queue with
+// the given bodyType and body content. Any headers are sent with this
+// request only, not with the requests for each relation in the queue, and
+// replace any the navigator would send with the same name.
+//
+// Sending a body with a GET is non-standard and many servers and proxies
+// will ignore or reject it; this is only for APIs which require it, such
+// as those which take complex queries in the body.
+//
+//     Navigator("http://api.example.com").
+//       Follow("search").
+//       GetWithBody("application/json", query,
+//         http.Header{"X-Query-Language": {"jmespath"}})
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) GetWithBody(bodyType string, body io.Reader, headers ...http.Header) (*http.Response, error) {
+	return n.withTipHeaders(headers...).send("GET", bodyType, body)
+}
+
+// Ping follows rels from the tip of the follow queue then performs a HEAD
+// request on the resulting resource, returning an error unless the
+// response has a 2xx status. The response body is never read, which
+// makes Ping a cheap liveness check for an API.
+//
+//     err := Navigator("http://api.example.com").Ping("products", "first")
+func (n navigator) Ping(rels ...string) error {
+	for _, rel := range rels {
+		n = n.Follow(rel)
+	}
+
+	res, err := n.send("HEAD", "", nil)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("Ping of %s failed: %s", effectiveURL(res, n.describe()), res.Status)
+	}
+
+	return nil
+}
+
+// Options performs an OPTIONS request on the tip of the follow queue.
+func (n navigator) Options() (*http.Response, error) {
+	return n.send("OPTIONS", "", nil)
+}
+
+// AllowedMethods performs an OPTIONS request on the tip of the follow
+// queue and returns the methods listed in the Allow header of the
+// response, so a client can check an action is permitted before
+// attempting it. An error is returned unless the response has a 2xx
+// status.
+//
+//     methods, err := Navigator("http://api.example.com").
+//       Follow("order").
+//       AllowedMethods()
+func (n navigator) AllowedMethods() ([]string, error) {
+	res, err := n.Options()
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("OPTIONS of %s failed: %s", effectiveURL(res, n.describe()), res.Status)
+	}
+
+	methods := []string{}
+	for _, header := range res.Header["Allow"] {
+		for _, method := range strings.Split(header, ",") {
+			if method = strings.TrimSpace(method); method != "" {
+				methods = append(methods, method)
+			}
+		}
+	}
+
+	return methods, nil
+}
+
+// PostForm performs a POST request on the tip of the follow queue with
+// the given form data.
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) PostForm(data url.Values) (*http.Response, error) {
+	return n.send("POST", "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
+}
+
+// PostMultipart performs a POST request on the tip of the follow queue
+// with a multipart/form-data body of the given fields and files, such as
+// for uploading files. Each file is sent with its key as the form field
+// name, and as its file name unless the reader has a Name method, like
+// *os.File. The parts are sent in order of name.
+//
+//     f, _ := os.Open("avatar.png")
+//     res, err := Navigator("http://api.example.com").
+//       Follow("avatar").
+//       PostMultipart(map[string]string{"alt": "Me"}, map[string]io.Reader{"image": f})
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) PostMultipart(fields map[string]string, files map[string]io.Reader) (*http.Response, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := w.WriteField(name, fields[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	names = make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		filename := name
+		if named, ok := files[name].(interface{ Name() string }); ok {
+			filename = filepath.Base(named.Name())
+		}
+
+		part, err := w.CreateFormFile(name, filename)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(part, files[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return n.send("POST", w.FormDataContentType(), &body)
+}
+
+// Patch parforms a PATCH request on the tip of the follow queue with the
+// given bodyType and body content. The body is handled the same as for
+// Post.
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) Patch(bodyType string, body io.Reader) (*http.Response, error) {
+	return n.send("PATCH", bodyType, body)
+}
+
+// Post performs a POST request on the tip of the follow queue with the
+// given bodyType and body content.
+//
+// So the body can be sent again if the request is redirected, it's read
+// into memory first unless it's a bytes.Buffer, bytes.Reader,
+// strings.Reader, or an io.Seeker which is rewound instead. Large bodies
+// should be given as an io.Seeker, such as an *os.File.
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) Post(bodyType string, body io.Reader) (*http.Response, error) {
+	return n.send("POST", bodyType, body)
+}
+
+// PostSized performs a POST request on the tip of the follow queue with
+// the given bodyType and body content, which is size bytes long. Unlike
+// Post, the body is streamed as it's read rather than read into memory
+// first, and sent with a Content-Length header instead of chunked, for
+// servers and proxies which don't accept chunked uploads. The body can't
+// be sent again, so a 307 or 308 redirect isn't followed.
+//
+//     f, _ := os.Open("video.mp4")
+//     info, _ := f.Stat()
+//     Navigator("http://api.example.com").
+//       Follow("uploads").
+//       PostSized("video/mp4", bufio.NewReader(f), info.Size(),
+//         http.Header{"Content-MD5": {checksum}})
+//
+// Any headers are sent with this request only, not with the requests for
+// each relation in the queue, and replace any the navigator would send
+// with the same name.
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) PostSized(bodyType string, body io.Reader, size int64, headers ...http.Header) (*http.Response, error) {
+	return n.withTipHeaders(headers...).sendSized("POST", bodyType, body, size)
+}
+
+// Put performs a PUT request on the tip of the follow queue with the
+// given bodyType and body content. The body is handled the same as for
+// Post.
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) Put(bodyType string, body io.Reader) (*http.Response, error) {
+	return n.send("PUT", bodyType, body)
+}
+
+// PostJSON performs a POST request on the tip of the follow queue with v
+// encoded as JSON.
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) PostJSON(v interface{}) (*http.Response, error) {
+	return n.sendJSON("POST", v)
+}
+
+// PutJSON performs a PUT request on the tip of the follow queue with v
+// encoded as JSON.
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) PutJSON(v interface{}) (*http.Response, error) {
+	return n.sendJSON("PUT", v)
+}
+
+// PatchJSON performs a PATCH request on the tip of the follow queue with
+// v encoded as JSON.
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) PatchJSON(v interface{}) (*http.Response, error) {
+	return n.sendJSON("PATCH", v)
+}
+
+// Update performs a GET request on the tip of the follow queue and
+// unmarshals the resource into v, then calls mutate with v and PUTs the
+// modified v back to the resource's self link as JSON. If the resource
+// had an ETag it's sent in an If-Match header, so the update fails rather
+// than overwriting any changes made since it was fetched. Conditions set
+// with IfMatch or IfNoneMatch are sent with the PUT, not the GET, with the
+// resource's ETag taking precedence over IfMatch.
+//
+//     var product Product
+//     res, err := Navigator("http://api.example.com").
+//       Follow("product").
+//       Update(&product, func(v interface{}) error {
+//         v.(*Product).Price = 10
+//         return nil
+//       })
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) Update(v interface{}, mutate func(v interface{}) error) (*http.Response, error) {
+	get := n
+	get.tipHeader = nil
+
+	body, res, err := get.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := n.decode(body, v); err != nil {
+		return nil, err
+	}
+
+	var doc halDocument
+	if err := n.decode(body, &doc); err != nil {
+		return nil, err
+	}
+
+	self, err := n.href(effectiveURL(res, n.rootUri), doc.Links, relation{rel: "self"})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mutate(v); err != nil {
+		return nil, err
+	}
+
+	put := n
+	put.path = []relation{}
+	put.rootUri = self
+	put.relocated = true
+	if etag := res.Header.Get("ETag"); etag != "" {
+		put = put.IfMatch(etag)
+	}
+
+	return put.PutJSON(v)
+}
+
+// sendJSON performs a request with the given method on the tip of the
+// follow queue, with v encoded as JSON as the body.
+func (n navigator) sendJSON(method string, v interface{}) (*http.Response, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.send(method, "application/json", bytes.NewReader(body))
+}
+
+// Delete performs a DELETE request on the tip of the follow queue.
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) Delete() (*http.Response, error) {
+	return n.send("DELETE", "", nil)
+}
+
+// send performs a request with the given method on the tip of the follow
+// queue. The Content-Type is set to bodyType unless it's empty.
+func (n navigator) send(method, bodyType string, body io.Reader) (*http.Response, error) {
+	return n.sendSized(method, bodyType, body, -1)
+}
+
+// sendSized is like send, but if size isn't negative the body is streamed
+// with size as its Content-Length, rather than made replayable.
+func (n navigator) sendSized(method, bodyType string, body io.Reader, size int64) (*http.Response, error) {
+	n, cancel := n.begin()
+
+	url, err := n.url()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	req, err := newHalRequest(method, url, body)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if size < 0 {
+		err = makeReplayable(req, body)
+	} else {
+		req.ContentLength = size
+		req.GetBody = nil
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if bodyType != "" {
+		req.Header.Add("Content-Type", bodyType)
+	}
+
+	res, err := n.doTip(req)
+	return cancelOnClose(res, err, cancel)
+}
+
+// doTip executes req as the request to the tip of the follow queue, which
+// is sent with the tipHeader as well as the headers of every request.
+func (n navigator) doTip(req *http.Request) (*http.Response, error) {
+	if len(n.tipHeader) > 0 {
+		n = n.WithHeaders(n.tipHeader)
+	}
+
+	return n.do(req)
+}
+
+// makeReplayable makes sure the body of req can be sent again, such as
+// when the HttpClient follows a 307 or 308 redirect. http.NewRequest
+// already handles bytes and strings readers; an io.Seeker is rewound, and
+// any other body is read into memory.
+func makeReplayable(req *http.Request, body io.Reader) error {
+	if body == nil || req.GetBody != nil {
+		return nil
+	}
+
+	if seeker, ok := body.(io.Seeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+
+		req.ContentLength = end - start
+		req.GetBody = func() (io.ReadCloser, error) {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, err
+			}
+			return ioutil.NopCloser(body), nil
+		}
+		return nil
+	}
+
+	buf, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = int64(len(buf))
+	req.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(buf)), nil
+	}
+	return nil
+}
+
+// EachPage performs a GET request on the tip of the follow queue and
+// calls fn with the response, then follows the "next" relation of that
+// response and repeats until a page without a "next" link is reached.
+// Iteration stops at the first error, including any returned by fn, or
+// when a "next" link points to a page which has already been visited;
+// see EndPagingOnCycle.
+//
+// The response body is buffered before fn is called, so fn is free to
+// read it (or not) without affecting the iteration.
+//
+//     err := Navigator("http://api.example.com").
+//       Follow("orders").
+//       EachPage(func(res *http.Response) error {
+//         ...
+//       })
+func (n navigator) EachPage(fn func(*http.Response) error) error {
+	page := n
+	depth := len(n.path)
+	visited := map[string]bool{}
+
+	for {
+		res, err := page.Get()
+		if err != nil {
+			return err
+		}
+
+		body, err := page.readBody(res, "")
+		res.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		current := effectiveURL(res, page.rootUri)
+		visited[current] = true
+
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err := fn(res); err != nil {
+			return err
+		}
+
+		var doc halDocument
+		if err := page.parseDocument(res, current, body, &doc); err != nil {
+			return err
+		}
+
+		links := doc.Links
+		if n.useLinkHeader {
+			links = links.Merge(parseLinkHeader(res.Header["Link"]))
+		}
+
+		// the next page is found the same way Follow("next") would
+		next, err := n.href(current, links, relation{rel: "next"})
+		if _, ok := err.(LinkNotFoundError); ok {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		depth++
+		if n.maxDepth > 0 && depth > n.maxDepth {
+			return MaxDepthExceededError{n.maxDepth}
+		}
+
+		if visited[next] && n.endPagingOnCycle {
+			return nil
+		}
+		if visited[next] {
+			return PageCycleError{next}
+		}
+		visited[next] = true
+
+		page.path = []relation{}
+		page.rootUri = next
+		page.relocated = true
+	}
+}
+
+// FollowParallel performs a GET request on each of the given relations of
+// the tip of the follow queue concurrently. The tip itself is only
+// requested once.
+//
+// Responses are returned in the same order as rels, regardless of the
+// order the requests complete in. If any of the requests fail, the
+// error will be a ParallelError with an entry for each of rels; the
+// entries for successful requests are nil, as are the responses for the
+// failed ones.
+//
+//     res, err := Navigator("http://api.example.com").
+//       FollowParallel("products", "customers")
+func (n navigator) FollowParallel(rels ...string) ([]*http.Response, error) {
+	n, cancel := n.begin()
+
+	url, links, err := n.tipLinks()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	responses := make([]*http.Response, len(rels))
+	errs := make(ParallelError, len(rels))
+
+	limit := n.concurrency
+	if limit <= 0 {
+		limit = len(rels)
+	}
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, rel := range rels {
+		wg.Add(1)
+		go func(i int, rel string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			responses[i], errs[i] = n.getHref(url, links, relation{rel: rel})
+		}(i, rel)
+	}
+	wg.Wait()
+
+	// the context is only canceled once every response has been closed
+	open := int32(1)
+	release := func() {
+		if atomic.AddInt32(&open, -1) == 0 {
+			cancel()
+		}
+	}
+	for _, res := range responses {
+		if res != nil {
+			atomic.AddInt32(&open, 1)
+			cancelOnClose(res, nil, release)
+		}
+	}
+	release()
+
+	for _, err := range errs {
+		if err != nil {
+			return responses, errs
+		}
+	}
+
+	return responses, nil
+}
+
+// FollowAll performs a GET request on each of the given relations of the
+// tip of the follow queue concurrently, like FollowParallel, and returns
+// the responses keyed by relation. If any of the requests fail the error
+// will be a ParallelError, with an entry for each of rels in order.
+//
+//     res, err := Navigator("http://api.example.com").
+//       WithConcurrency(2).
+//       FollowAll("products", "customers", "orders")
+//     products := res["products"]
+func (n navigator) FollowAll(rels ...string) (map[string]*http.Response, error) {
+	responses, err := n.FollowParallel(rels...)
+	if responses == nil {
+		return nil, err
+	}
+
+	m := make(map[string]*http.Response, len(rels))
+	for i, rel := range rels {
+		if responses[i] != nil {
+			m[rel] = responses[i]
+		}
+	}
+
+	return m, err
+}
+
+// getHref performs a GET request on the URL of a relation in links.
+func (n navigator) getHref(base string, links Links, link relation) (*http.Response, error) {
+	url, err := n.href(base, links, link)
+	if err != nil {
+		return nil, err
+	}
 
-	return navigator{
-		HttpClient: n.HttpClient,
-		path:       relations,
-		rootUri:    n.rootUri,
+	req, err := newHalRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
 	}
+
+	return n.do(req)
 }
 
-// Location follows the Location header from a response.  It makes the URI
-// absolute, if necessary.
-func (n navigator) Location(resp *http.Response) (navigator, error) {
-	_, exists := resp.Header["Location"]
-	if !exists {
-		return n, fmt.Errorf("Response didn't contain a Location header")
+// GetBody performs a GET request on the tip of the follow queue and
+// returns the body of the response, for callers which parse it
+// themselves. The body has been read and closed, so the response is only
+// for its status and headers.
+//
+//     body, res, err := Navigator("http://api.example.com").
+//       Follow("report").
+//       GetBody()
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) GetBody() ([]byte, *http.Response, error) {
+	res, err := n.Get()
+	if err != nil {
+		return nil, nil, err
 	}
-	loc := resp.Header.Get("Location")
-	lurl, err := makeAbsoluteIfNecessary(loc, n.rootUri)
+	defer res.Body.Close()
+
+	body, err := n.readBody(res, "")
 	if err != nil {
-		return n, err
+		return nil, nil, err
 	}
-	return navigator{
-		HttpClient: n.HttpClient,
-		path:       []relation{},
-		rootUri:    lurl,
-	}, nil
+
+	return body, res, nil
 }
 
-// url returns the URL of the tip of the follow queue. Will follow the
-// usual pattern of requests.
-func (n navigator) url() (string, error) {
-	url := n.rootUri
+// Unmarshal is a shorthand for Get followed by json.Unmarshal. Handles
+// closing the response body and unmarshalling the body.
+//
+// If v embeds Links, the links of the resource are unmarshalled along
+// with the rest of it, so navigation can continue from v.
+//
+// A 204 No Content response, or any response with an empty body, leaves
+// v unchanged.
+func (n navigator) Unmarshal(v interface{}) error {
+	body, res, err := n.GetBody()
+	if err != nil {
+		return err
+	}
 
-	for _, link := range n.path {
-		links, err := n.getLinks(url)
-		if err != nil {
-			return "", fmt.Errorf("Error getting links (%s, %v): %v", url, links, err)
-		}
+	if isEmptyBody(res, body) {
+		return nil
+	}
 
-		if _, ok := links.Items[link.rel]; !ok {
-			return "", LinkNotFoundError{link.rel, links.Items}
-		}
+	return n.decode(body, v)
+}
 
-		url, err = links.HrefParams(link.rel, link.params)
-		if err != nil {
-			return "", fmt.Errorf("Error getting url (%v, %v): %v", link.rel, link.params, err)
-		}
+// Stream performs a GET request on the tip of the follow queue, which
+// must respond with a JSON array, and decodes each element of the array
+// into v in turn, calling each after every one. The response is decoded as
+// it's read, so the whole array is never held in memory. Returning an
+// error from each stops the stream and returns the error.
+//
+//     var product Product
+//     err := Navigator("http://api.example.com").
+//       Follow("products").
+//       Stream(&product, func() error {
+//         fmt.Println(product.Name)
+//         return nil
+//       })
+func (n navigator) Stream(v interface{}, each func() error) error {
+	res, err := n.Get()
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
 
-		if url == "" {
-			return "", InvalidUrlError{url}
-		}
+	dec := json.NewDecoder(res.Body)
 
-		url, err = makeAbsoluteIfNecessary(url, n.rootUri)
-		if err != nil {
-			return "", fmt.Errorf("Error making url absolute: %v", err)
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("Expected a JSON array but got %v", tok)
+	}
+
+	for dec.More() {
+		if err := dec.Decode(v); err != nil {
+			return err
+		}
+		if err := each(); err != nil {
+			return err
 		}
 	}
 
-	return url, nil
+	_, err = dec.Token()
+	return err
 }
 
-// makeAbsoluteIfNecessary takes the current url and the root url, and
-// will make the current URL absolute by using the root's Host, Scheme,
-// and credentials if current isn't already absolute.
-func makeAbsoluteIfNecessary(current, root string) (string, error) {
-	currentUri, err := url.Parse(current)
+// UnmarshalResponse is a shorthand for Get followed by json.Unmarshal,
+// like Unmarshal, which also returns the response for access to its
+// status and headers. The response body has already been read and
+// closed. An empty body leaves v unchanged, as for Unmarshal.
+//
+//     var product Product
+//     res, err := Navigator("http://api.example.com").
+//       Follow("product").
+//       UnmarshalResponse(&product)
+//     etag := res.Header.Get("ETag")
+func (n navigator) UnmarshalResponse(v interface{}) (*http.Response, error) {
+	body, res, err := n.GetBody()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	if currentUri.IsAbs() {
-		return current, nil
+	if isEmptyBody(res, body) {
+		return res, nil
 	}
 
-	rootUri, err := url.Parse(root)
-	if err != nil {
-		return "", err
+	if err := n.decode(body, v); err != nil {
+		return nil, err
 	}
 
-	currentUri.Scheme = rootUri.Scheme
-	currentUri.Host = rootUri.Host
-	currentUri.User = rootUri.User
-
-	return currentUri.String(), nil
+	return res, nil
 }
 
-// Get performs a GET request on the tip of the follow queue.
+// GetResource is a shorthand for Get followed by json.Unmarshal into v,
+// which also returns the links of the resource whether or not v embeds
+// Links. Handles closing the response body.
 //
-// When a navigator is evaluated it will first request the root, then
-// request each relation on the queue until it reaches the tip. Once the
-// tip is reached it will defer to the calling method. In the case of GET
-// the last request will just be returned. For Post it will issue a post
-// to the URL of the last relation. Any error along the way will terminate
-// the walk and return immediately.
-func (n navigator) Get() (*http.Response, error) {
-	url, err := n.url()
+//     var product Product
+//     links, err := Navigator("http://api.example.com").
+//       Follow("product").
+//       GetResource(&product)
+func (n navigator) GetResource(v interface{}) (*Links, error) {
+	body, _, err := n.GetBody()
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := newHalRequest("GET", url, nil)
-	if err != nil {
+	if err := n.decode(body, v); err != nil {
 		return nil, err
 	}
 
-	return n.HttpClient.Do(req)
+	var doc halDocument
+	if err := n.decode(body, &doc); err != nil {
+		return nil, err
+	}
+
+	return &doc.Links, nil
 }
 
-// Options performs an OPTIONS request on the tip of the follow queue.
-func (n navigator) Options() (*http.Response, error) {
+// SelfHref performs a GET request on the tip of the follow queue and
+// returns the absolute URL of the "self" link of the resource. This is
+// the canonical URL of the resource, which can differ from the URL that
+// was requested to reach it.
+func (n navigator) SelfHref() (string, error) {
+	n, cancel := n.begin()
+	defer cancel()
+
+	url, links, err := n.tipLinks()
+	if err != nil {
+		return "", err
+	}
+
+	return n.href(url, links, relation{rel: "self"})
+}
+
+// FinalURL performs a GET request on the tip of the follow queue and
+// returns the URL the resource was actually fetched from, once the
+// HttpClient has followed any redirects. Relative links in the resource
+// are resolved against this URL.
+func (n navigator) FinalURL() (string, error) {
+	n, cancel := n.begin()
+	defer cancel()
+
 	url, err := n.url()
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+
+	req, err := newHalRequest("GET", url, nil)
+	if err != nil {
+		return "", err
 	}
 
-	req, err := newHalRequest("OPTIONS", url, nil)
+	res, err := n.do(req)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
+	res.Body.Close()
 
-	return n.HttpClient.Do(req)
+	return effectiveURL(res, url), nil
 }
 
-// PostForm performs a POST request on the tip of the follow queue with
-// the given form data.
+// TraceStep is a relation looked up by a navigator, as recorded by
+// Trace. URL is where the document the relation was looked up in was
+// fetched from, and Body is the start of that document, or nil if it was
+// cached by an earlier evaluation (see CacheRelation).
+type TraceStep struct {
+	Rel  string
+	URL  string
+	Body []byte
+}
+
+// Trace resolves the URL of the tip of the follow queue like Get would,
+// without requesting it, and returns every relation looked up on the way
+// along with the document it was looked up in. It's for debugging
+// navigation which ends up somewhere unexpected. The steps up to a
+// failure are returned along with the error.
 //
-// See GET for a note on how the navigator executes requests.
-func (n navigator) PostForm(data url.Values) (*http.Response, error) {
+//     url, steps, err := Navigator("http://api.example.com").
+//       Follow("orders").
+//       Follow("latest").
+//       Trace()
+//     for _, step := range steps {
+//       log.Printf("%s in %s: %s", step.Rel, step.URL, step.Body)
+//     }
+//
+// Bodies longer than 64KB are truncated.
+func (n navigator) Trace() (string, []TraceStep, error) {
+	n, cancel := n.begin()
+	defer cancel()
+
+	var steps []TraceStep
+	n.trace = func(step TraceStep) {
+		steps = append(steps, step)
+	}
+
 	url, err := n.url()
+	return url, steps, err
+}
+
+// Links performs a GET request on the tip of the follow queue and returns
+// every link of the resource, so they can be inspected before choosing
+// which to follow.
+func (n navigator) Links() (Links, error) {
+	n, cancel := n.begin()
+	defer cancel()
+
+	_, links, err := n.tipLinks()
+	return links, err
+}
+
+// IsTemplated performs a GET request on the tip of the follow queue and
+// returns whether the link the navigator would follow for rel is a URI
+// template, so a client can decide between Follow and Followf.
+func (n navigator) IsTemplated(rel string) (bool, error) {
+	n, cancel := n.begin()
+	defer cancel()
+
+	_, links, err := n.tipLinks()
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	req, err := newHalRequest("PATCH", url, strings.NewReader(data.Encode()))
+	match, err := n.findRel(links, rel)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	link, err := n.selectLink(links, match, relation{rel: rel})
+	if err != nil {
+		return false, err
+	}
 
-	return n.HttpClient.Do(req)
+	return link.Templated, nil
 }
 
-// Patch parforms a PATCH request on the tip of the follow queue with the
-// given bodyType and body content.
-//
-// See GET for a note on how the navigator executes requests.
-func (n navigator) Patch(bodyType string, body io.Reader) (*http.Response, error) {
-	url, err := n.url()
+// Rels performs a GET request on the tip of the follow queue and returns
+// the relations of the resource, sorted by name.
+func (n navigator) Rels() ([]string, error) {
+	n, cancel := n.begin()
+	defer cancel()
+
+	_, links, err := n.tipLinks()
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := newHalRequest("PATCH", url, body)
+	return links.Rels(), nil
+}
+
+// Deprecations performs a GET request on the tip of the follow queue and
+// returns every link of the resource which has been marked as
+// deprecated, ordered by relation.
+func (n navigator) Deprecations() ([]DeprecatedLink, error) {
+	n, cancel := n.begin()
+	defer cancel()
+
+	_, links, err := n.tipLinks()
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Content-Type", bodyType)
-
-	return n.HttpClient.Do(req)
+	return links.Deprecations(), nil
 }
 
-// Post performs a POST request on the tip of the follow queue with the
-// given bodyType and body content.
-//
-// See GET for a note on how the navigator executes requests.
-func (n navigator) Post(bodyType string, body io.Reader) (*http.Response, error) {
-	url, err := n.url()
+// tipLinks performs a GET request on the tip of the follow queue and
+// returns the URL it was fetched from and the links of the resource.
+func (n navigator) tipLinks() (string, Links, error) {
+	cache := n.newLinkCache()
+
+	url, ttl, err := n.resolve(cache)
 	if err != nil {
-		return nil, err
+		return "", Links{}, err
 	}
 
-	req, err := newHalRequest("POST", url, body)
+	doc, err := n.getCachedLinks(cache, url, ttl)
 	if err != nil {
-		return nil, err
+		return "", Links{}, fmt.Errorf("Error getting links (%s, %v): %w", url, doc.links, err)
 	}
 
-	req.Header.Add("Content-Type", bodyType)
+	return doc.url, doc.links, nil
+}
 
-	return n.HttpClient.Do(req)
+// isEmptyBody returns whether a response has no content to unmarshal.
+func isEmptyBody(res *http.Response, body []byte) bool {
+	return res.StatusCode == http.StatusNoContent || len(bytes.TrimSpace(body)) == 0
 }
 
-// Delete performs a DELETE request on the tip of the follow queue.
-//
-// See GET for a note on how the navigator executes requests.
-func (n navigator) Delete() (*http.Response, error) {
-	url, err := n.url()
-	if err != nil {
-		return nil, err
+// begin starts a single evaluation of the navigator, deriving a context
+// with the navigator's timeout. The returned cancel func must be called
+// once the evaluation, and any response it produced, is finished with.
+func (n navigator) begin() (navigator, context.CancelFunc) {
+	// with nothing to follow, the tip is the root document
+	n.rootRequest = len(n.path) == 0 && !n.relocated
+
+	if n.timeout <= 0 {
+		return n, func() {}
+	}
+
+	parent := n.ctx
+	if parent == nil {
+		parent = context.Background()
 	}
 
-	req, err := newHalRequest("DELETE", url, nil)
+	ctx, cancel := context.WithTimeout(parent, n.timeout)
+	n.ctx = ctx
+	return n, cancel
+}
+
+// cancelOnClose calls cancel once the body of res is closed, so the
+// context of an evaluation lives for as long as its response is being
+// read. If there's no response cancel is called immediately.
+func cancelOnClose(res *http.Response, err error, cancel context.CancelFunc) (*http.Response, error) {
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 
-	return n.HttpClient.Do(req)
+	res.Body = cancelingBody{res.Body, cancel}
+	return res, nil
 }
 
-// Unmarshal is a shorthand for Get followed by json.Unmarshal. Handles
-// closing the response body and unmarshalling the body.
-func (n navigator) Unmarshal(v interface{}) error {
-	res, err := n.Get()
-	if err != nil {
-		return err
+// cancelingBody is a response body which cancels a context when closed.
+type cancelingBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// do applies the navigator's headers to a request and executes it with
+// the HttpClient.
+func (n navigator) do(req *http.Request) (*http.Response, error) {
+	if n.ctx != nil {
+		req = req.WithContext(n.ctx)
+	}
+
+	if n.accept != nil && len(n.accept) == 0 {
+		req.Header.Del("Accept")
+	} else if n.accept != nil {
+		req.Header.Set("Accept", strings.Join(n.accept, ", "))
+	}
+
+	for k, v := range n.header {
+		req.Header[k] = append([]string{}, v...)
+	}
+
+	start := time.Now()
+	res, err := n.HttpClient.Do(req)
+	if n.observer != nil {
+		n.observer(req, res, err, time.Since(start))
+	}
+
+	if err != nil && n.rootRequest && req.Method == "GET" {
+		return nil, RootFetchError{req.URL.String(), err}
+	}
+
+	if err == nil && n.checkStatus && res.StatusCode >= 400 {
+		return nil, n.statusError(req, res)
 	}
+
+	return res, err
+}
+
+// problemMediaType is the media type of RFC 7807 problem documents.
+const problemMediaType = "application/problem+json"
+
+// statusError reads and closes the body of an error response, returning a
+// ProblemDetails if it's a problem document or a HTTPError otherwise,
+// wrapped in a PreconditionFailedError for a 412 response. No more of the
+// body is read than maxErrorBodyLength, or the navigator's maximum
+// response size if that's smaller.
+func (n navigator) statusError(req *http.Request, res *http.Response) error {
 	defer res.Body.Close()
 
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return err
+	limit := int64(maxErrorBodyLength)
+	if n.maxResponseSize > 0 && n.maxResponseSize < limit {
+		limit = n.maxResponseSize
+	}
+
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, limit))
+	httpErr := HTTPError{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Body:       truncate(body),
 	}
 
-	return json.Unmarshal(body, &v)
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+
+	var err error = httpErr
+	if mediaType == problemMediaType {
+		problem := ProblemDetails{}
+		if json.Unmarshal(body, &problem) == nil {
+			problem.HTTPError = httpErr
+			err = problem
+		}
+	}
+
+	if res.StatusCode == http.StatusPreconditionFailed {
+		return PreconditionFailedError{err}
+	}
+
+	return err
 }
 
 func newHalRequest(method, url string, body io.Reader) (*http.Request, error) {
@@ -324,35 +2389,179 @@ func newHalRequest(method, url string, body io.Reader) (*http.Request, error) {
 		return nil, err
 	}
 
-	req.Header.Add("Accept", "application/hal+json, application/json")
+	req.Header.Add("Accept", strings.Join(defaultAccept, ", "))
 
 	return req, nil
 }
 
+// defaultAccept are the media types requested by default, in order of
+// preference.
+var defaultAccept = []string{"application/hal+json", "application/json"}
+
 // getLinks does a GET on a particular URL and try to deserialise it into
 // a HAL links collection.
-func (n navigator) getLinks(uri string) (Links, error) {
+func (n navigator) getLinks(uri string) (document, error) {
+	var m halDocument
+
+	res, body, err := n.getDocument(uri, &m)
+	if err != nil {
+		return document{}, err
+	}
+
+	if n.trace == nil {
+		body = nil
+	}
+
+	if n.useLinkHeader {
+		if header := parseLinkHeader(res.Header["Link"]); len(header.Items) > 0 {
+			m.Links = m.Links.Merge(header)
+		}
+	}
+
+	return document{effectiveURL(res, uri), res.Header.Get("Content-Type"), m.Links, m.Embedded, body}, nil
+}
+
+// getDocument does a GET on a particular URL and deserialises the JSON
+// response into v. The response is returned with its body closed, along
+// with the body that was read.
+func (n navigator) getDocument(uri string, v interface{}) (*http.Response, []byte, error) {
 	req, err := newHalRequest("GET", uri, nil)
 	if err != nil {
-		return Links{}, err
+		return nil, nil, err
 	}
 
-	res, err := n.HttpClient.Do(req)
+	res, err := n.do(req)
 	if err != nil {
-		return Links{}, err
+		return nil, nil, err
 	}
 	defer res.Body.Close()
 
-	body, err := ioutil.ReadAll(res.Body)
+	body, err := n.readBody(res, uri)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := n.parseDocument(res, uri, body, v); err != nil {
+		return nil, nil, err
+	}
+
+	return res, body, nil
+}
+
+// parseDocument decodes the body of a response requested from uri into v,
+// describing the response if it isn't JSON rather than failing to parse
+// it.
+func (n navigator) parseDocument(res *http.Response, uri string, body []byte, v interface{}) error {
+	if err := n.decode(body, v); err != nil {
+		contentType := res.Header.Get("Content-Type")
+		if !isJSONMediaType(contentType) {
+			return UnexpectedContentTypeError{effectiveURL(res, uri), contentType, res.Status, truncate(body)}
+		}
+		return fmt.Errorf("Unable to unmarshal '%s': %v", truncate(body), err)
+	}
+
+	return nil
+}
+
+// readBody reads the body of a response requested from uri, up to the
+// navigator's maximum response size, and verifies it if the navigator
+// verifies digests. The body isn't closed.
+func (n navigator) readBody(res *http.Response, uri string) ([]byte, error) {
+	body, err := n.readLimited(res, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.verifyDigest {
+		if err := verifyBody(res, uri, body); err != nil {
+			return nil, err
+		}
+	}
+
+	return body, nil
+}
+
+// readLimited reads the body of a response requested from uri, returning
+// a ResponseTooLargeError if it's larger than the navigator's maximum
+// response size. The body isn't closed.
+func (n navigator) readLimited(res *http.Response, uri string) ([]byte, error) {
+	if n.maxResponseSize <= 0 {
+		return ioutil.ReadAll(res.Body)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, n.maxResponseSize+1))
+	if err == nil && int64(len(body)) > n.maxResponseSize {
+		err = ResponseTooLargeError{effectiveURL(res, uri), n.maxResponseSize}
+	}
+	return body, err
+}
+
+// describe returns the root of the navigator and the relations in its
+// follow queue, for errors about the tip when its URL isn't known.
+func (n navigator) describe() string {
+	parts := []string{n.rootUri}
+	for _, link := range n.path {
+		switch {
+		case link.url != "":
+			parts = append(parts, link.url)
+		case link.embedded != "":
+			parts = append(parts, "_embedded "+link.embedded)
+		default:
+			parts = append(parts, link.rel)
+		}
+	}
+
+	return strings.Join(parts, " -> ")
+}
+
+// effectiveURL returns the URL a response was fetched from, once any
+// redirects were followed, or uri if the response doesn't say.
+func effectiveURL(res *http.Response, uri string) string {
+	if res.Request == nil || res.Request.URL == nil {
+		return uri
+	}
+
+	return res.Request.URL.String()
+}
+
+// isJSONMediaType returns whether a Content-Type is JSON, including HAL
+// and the other +json media types.
+func isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
 	if err != nil {
-		return Links{}, err
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// maxSnippetLength is how much of a response body is included in errors.
+const maxSnippetLength = 200
+
+// truncate shortens a response body for including in an error.
+func truncate(body []byte) string {
+	if len(body) <= maxSnippetLength {
+		return string(body)
 	}
 
-	var m Links
+	return string(body[:maxSnippetLength]) + "..."
+}
+
+// maxErrorBodyLength is how much of an error response is read, which is
+// plenty for a problem document. A larger one is reported as a HTTPError.
+const maxErrorBodyLength = 64 * 1024
 
-	if err := json.Unmarshal(body, &m); err != nil {
-		return Links{}, fmt.Errorf("Unable to unmarshal '%s': %v", string(body), err)
+// maxTraceBodyLength is how much of each document is kept in a trace.
+const maxTraceBodyLength = 64 * 1024
+
+// truncateBytes returns a copy of at most the first max bytes of b.
+func truncateBytes(b []byte, max int) []byte {
+	if b == nil {
+		return nil
+	}
+	if len(b) > max {
+		b = b[:max]
 	}
 
-	return m, nil
+	return append([]byte{}, b...)
 }
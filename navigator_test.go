@@ -1,12 +1,20 @@
 package halgo
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func createTestHttpServer() (*httptest.Server, map[string]int) {
@@ -21,6 +29,17 @@ func createTestHttpServer() (*httptest.Server, map[string]int) {
         "next": { "href": "http://%s/2nd" },
         "relative": { "href": "/2nd" },
         "child": { "href": "/child" },
+        "empty": { "href": "" },
+        "broken": { "href": "/broken" },
+        "cased": { "href": "/cased" },
+        "pages": { "href": "/pages/1" },
+        "slow": { "href": "/delay/50" },
+        "fast": { "href": "/delay/0" },
+        "deprecated": { "href": "/deprecated" },
+        "profiled": [
+          { "href": "/a/1", "profile": "http://example.com/profiles/v1" },
+          { "href": "/a/2", "profile": "http://example.com/profiles/v2" }
+        ],
         "one": { "href": "http://%s/a/{id}", "templated": true }
       }
     }`, r.Host, r.Host)
@@ -43,6 +62,47 @@ func createTestHttpServer() (*httptest.Server, map[string]int) {
 		fmt.Fprintf(w, `{ "_links": { "parent": { "href": "/" } } }`)
 	})
 
+	r.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	r.HandleFunc("/cased", func(w http.ResponseWriter, r *http.Request) {
+		hits["/cased"] += 1
+		fmt.Fprintf(w, `{ "_links": { "Item": { "href": "/a/1" }, "ITEM": { "href": "/a/2" } } }`)
+	})
+
+	r.HandleFunc("/pages/{n}", func(w http.ResponseWriter, r *http.Request) {
+		n := mux.Vars(r)["n"]
+		hits["/pages/"+n] += 1
+		switch n {
+		case "1":
+			fmt.Fprintf(w, `{ "_links": { "next": { "href": "/pages/2" } }, "page": 1 }`)
+		case "2":
+			fmt.Fprintf(w, `{ "_links": { "next": { "href": "/pages/3" } }, "page": 2 }`)
+		default:
+			fmt.Fprintf(w, `{ "_links": { "prev": { "href": "/pages/2" } }, "page": 3 }`)
+		}
+	})
+
+	r.HandleFunc("/delay/{ms}", func(w http.ResponseWriter, r *http.Request) {
+		ms, _ := strconv.Atoi(mux.Vars(r)["ms"])
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+		fmt.Fprintf(w, `{ "delay": %d }`, ms)
+	})
+
+	r.HandleFunc("/deprecated", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{
+      "_links": {
+        "self": { "href": "/deprecated" },
+        "old": { "href": "/old", "deprecation": "http://example.com/docs/old" },
+        "older": [
+          { "href": "/older/1", "deprecation": "http://example.com/docs/older" },
+          { "href": "/older/2" }
+        ]
+      }
+    }`)
+	})
+
 	return httptest.NewServer(r), hits
 }
 
@@ -240,3 +300,2345 @@ func TestFollowingALink(t *testing.T) {
 		t.Errorf("Expected 1 request to /2nd, got %d", hits["/2nd"])
 	}
 }
+
+func TestFollowingALinkCaseInsensitively(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	_, err := Navigator(ts.URL).Follow("Next").Get()
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Errorf("Expected LinkNotFoundError by default, got %v", err)
+	}
+
+	res, err := Navigator(ts.URL).CaseInsensitiveRels(true).Follow("Next").Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Request.URL.String() != ts.URL+"/2nd" {
+		t.Errorf("Expected url to be %s, got %s", ts.URL+"/2nd", res.Request.URL)
+	}
+
+	if hits["/2nd"] != 1 {
+		t.Errorf("Expected 1 request to /2nd, got %d", hits["/2nd"])
+	}
+
+	_, err = Navigator(ts.URL).CaseInsensitiveRels(true).Follow("Missing").Get()
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Errorf("Expected LinkNotFoundError when nothing matches, got %v", err)
+	}
+}
+
+func TestFollowingAnAmbiguousLinkCaseInsensitively(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	nav := Navigator(ts.URL).CaseInsensitiveRels(true).Follow("cased")
+
+	curl, err := nav.Follow("ITEM").url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if curl != ts.URL+"/a/2" {
+		t.Errorf("Expected exact match %s, got %s", ts.URL+"/a/2", curl)
+	}
+
+	_, err = nav.Follow("item").url()
+	if _, ok := err.(AmbiguousRelationError); !ok {
+		t.Errorf("Expected AmbiguousRelationError, got %v", err)
+	}
+}
+
+func TestEachPage(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	pages := []string{}
+	err := Navigator(ts.URL).Follow("pages").EachPage(func(res *http.Response) error {
+		pages = append(pages, res.Request.URL.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "[/pages/1 /pages/2 /pages/3]"; fmt.Sprint(pages) != expected {
+		t.Errorf("Expected pages %s, got %v", expected, pages)
+	}
+
+	if hits["/pages/3"] != 1 {
+		t.Errorf("Expected 1 request to /pages/3, got %d", hits["/pages/3"])
+	}
+}
+
+func TestEachPageStopsOnError(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	stop := errors.New("stop")
+	err := Navigator(ts.URL).Follow("pages").EachPage(func(res *http.Response) error {
+		return stop
+	})
+	if err != stop {
+		t.Errorf("Expected callback error, got %v", err)
+	}
+
+	if hits["/pages/2"] != 0 {
+		t.Errorf("Expected no requests to /pages/2, got %d", hits["/pages/2"])
+	}
+}
+
+func TestEachPageUsesNavigatorOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pages/1":
+			fmt.Fprint(w, `{ "_links": { "Next": { "href": "/pages/{n}" } } }`)
+		case "/pages/2":
+			w.Header().Set("Link", `</pages/3>; rel="next"`)
+			fmt.Fprint(w, `{}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer ts.Close()
+
+	pages := []string{}
+	err := Navigator(ts.URL + "/pages/1").
+		CaseInsensitiveRels(true).
+		UseLinkHeader(true).
+		WithTemplateExpander(func(href string, params P) (string, error) {
+			return strings.Replace(href, "{n}", "2", 1), nil
+		}).
+		EachPage(func(res *http.Response) error {
+			pages = append(pages, res.Request.URL.Path)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "[/pages/1 /pages/2 /pages/3]"; fmt.Sprint(pages) != expected {
+		t.Errorf("Expected pages %s, got %v", expected, pages)
+	}
+}
+
+func TestFollowParallelPreservesOrder(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	res, err := Navigator(ts.URL).FollowParallel("slow", "fast", "next")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range []string{"/delay/50", "/delay/0", "/2nd"} {
+		if res[i].Request.URL.Path != expected {
+			t.Errorf("Expected response %d to be for %s, got %s", i, expected, res[i].Request.URL.Path)
+		}
+	}
+
+	if hits["/"] != 1 {
+		t.Errorf("Expected 1 request to /, got %d", hits["/"])
+	}
+}
+
+func TestFollowParallelWithFailures(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	res, err := Navigator(ts.URL).FollowParallel("slow", "missing", "fast")
+	errs, ok := err.(ParallelError)
+	if !ok {
+		t.Fatalf("Expected ParallelError, got %v", err)
+	}
+
+	if len(errs) != 3 || errs[0] != nil || errs[2] != nil {
+		t.Errorf("Expected only the second request to fail, got %v", errs)
+	}
+
+	if _, ok := errs[1].(LinkNotFoundError); !ok {
+		t.Errorf("Expected LinkNotFoundError, got %v", errs[1])
+	}
+
+	if res[1] != nil || res[0] == nil || res[2] == nil {
+		t.Errorf("Expected responses for successful requests only, got %v", res)
+	}
+}
+
+func TestUnmarshalPopulatesEmbeddedLinks(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	var child struct{ Links }
+	if err := Navigator(ts.URL).Follow("child").Unmarshal(&child); err != nil {
+		t.Fatal(err)
+	}
+
+	if href, _ := child.Href("parent"); href != "/" {
+		t.Errorf("Expected parent link to be /, got %s", href)
+	}
+}
+
+func TestGetResource(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	var v struct{ Page int }
+	links, err := Navigator(ts.URL).Follow("pages").GetResource(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Page != 1 {
+		t.Errorf("Expected page to be 1, got %d", v.Page)
+	}
+
+	if href, _ := links.Href("next"); href != "/pages/2" {
+		t.Errorf("Expected next link to be /pages/2, got %s", href)
+	}
+}
+
+func TestAuthIsSentWithEveryRequest(t *testing.T) {
+	auths := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{ "_links": { "self": { "href": "/" } } }`)
+	}))
+	defer ts.Close()
+
+	base := Navigator(ts.URL)
+
+	if _, err := base.WithBasicAuth("user", "pass").Follow("self").Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := base.WithBearerToken("abc").Follow("self").Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := base.Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"Basic dXNlcjpwYXNz", "Basic dXNlcjpwYXNz", "Bearer abc", "Bearer abc", ""}
+	if fmt.Sprint(auths) != fmt.Sprint(expected) {
+		t.Errorf("Expected Authorization headers %q, got %q", expected, auths)
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	agents := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.UserAgent())
+		fmt.Fprint(w, `{ "_links": { "self": { "href": "/" } } }`)
+	}))
+	defer ts.Close()
+
+	if _, err := Navigator(ts.URL).WithUserAgent("halgo-test/1.0").Follow("self").Follow("self").Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"halgo-test/1.0", "halgo-test/1.0", "halgo-test/1.0"}
+	if fmt.Sprint(agents) != fmt.Sprint(expected) {
+		t.Errorf("Expected User-Agent headers %q, got %q", expected, agents)
+	}
+}
+
+func TestSelfHref(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	href, err := Navigator(ts.URL).Follow("child").Follow("parent").SelfHref()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if href != ts.URL+"/" {
+		t.Errorf("Expected self to be %s, got %s", ts.URL+"/", href)
+	}
+
+	_, err = Navigator(ts.URL).Follow("child").SelfHref()
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Errorf("Expected LinkNotFoundError, got %v", err)
+	}
+}
+
+func TestSendJSON(t *testing.T) {
+	var method, contentType, body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		contentType = r.Header.Get("Content-Type")
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer ts.Close()
+
+	nav := Navigator(ts.URL)
+	sends := map[string]func(interface{}) (*http.Response, error){
+		"POST":  nav.PostJSON,
+		"PUT":   nav.PutJSON,
+		"PATCH": nav.PatchJSON,
+	}
+
+	for expected, send := range sends {
+		if _, err := send(map[string]string{"name": "James"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if method != expected {
+			t.Errorf("Expected %s, got %s", expected, method)
+		}
+
+		if contentType != "application/json" {
+			t.Errorf("Expected JSON Content-Type, got %s", contentType)
+		}
+
+		if body != `{"name":"James"}` {
+			t.Errorf("Expected JSON body, got %s", body)
+		}
+	}
+
+	if _, err := nav.PutJSON(func() {}); err == nil {
+		t.Errorf("Expected an error encoding a func")
+	}
+}
+
+func TestPostMultipart(t *testing.T) {
+	var method, alt, filename, image string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		alt = r.FormValue("alt")
+
+		f, header, err := r.FormFile("image")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer f.Close()
+
+		b, _ := ioutil.ReadAll(f)
+		filename, image = header.Filename, string(b)
+	}))
+	defer ts.Close()
+
+	_, err := Navigator(ts.URL).PostMultipart(
+		map[string]string{"alt": "Me"},
+		map[string]io.Reader{"image": strings.NewReader("PNG")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if method != "POST" {
+		t.Errorf("Expected POST, got %s", method)
+	}
+
+	if alt != "Me" {
+		t.Errorf("Expected alt to be Me, got %s", alt)
+	}
+
+	if filename != "image" || image != "PNG" {
+		t.Errorf("Expected image file with contents PNG, got %s with %s", filename, image)
+	}
+}
+
+func TestPostForm(t *testing.T) {
+	var method, contentType, name string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		contentType = r.Header.Get("Content-Type")
+		name = r.PostFormValue("name")
+	}))
+	defer ts.Close()
+
+	_, err := Navigator(ts.URL).PostForm(url.Values{"name": {"James"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if method != "POST" {
+		t.Errorf("Expected POST, got %s", method)
+	}
+
+	if contentType != "application/x-www-form-urlencoded" {
+		t.Errorf("Expected form Content-Type, got %s", contentType)
+	}
+
+	if name != "James" {
+		t.Errorf("Expected name to be James, got %s", name)
+	}
+}
+
+func TestDeprecations(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	deprecated, err := Navigator(ts.URL).Follow("deprecated").Deprecations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []DeprecatedLink{
+		{"old", "/old", "http://example.com/docs/old"},
+		{"older", "/older/1", "http://example.com/docs/older"},
+	}
+	if fmt.Sprint(deprecated) != fmt.Sprint(expected) {
+		t.Errorf("Expected deprecations %v, got %v", expected, deprecated)
+	}
+}
+
+func TestDocumentsAreOnlyRequestedOncePerEvaluation(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	nav := Navigator(ts.URL).Follow("self").Follow("self").Follow("self")
+
+	if _, err := nav.SelfHref(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the root is requested as both ts.URL and ts.URL/
+	if hits["/"] != 2 {
+		t.Errorf("Expected 2 requests to /, got %d", hits["/"])
+	}
+
+	if _, err := nav.SelfHref(); err != nil {
+		t.Fatal(err)
+	}
+
+	if hits["/"] != 4 {
+		t.Errorf("Expected 4 requests to / after a second evaluation, got %d", hits["/"])
+	}
+}
+
+func TestRootFetchError(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	ts.Close()
+
+	_, err := Navigator(ts.URL).Follow("next").Get()
+
+	var rootErr RootFetchError
+	if !errors.As(err, &rootErr) {
+		t.Fatalf("Expected RootFetchError, got %v", err)
+	}
+
+	if rootErr.URL != ts.URL {
+		t.Errorf("Expected URL to be %s, got %s", ts.URL, rootErr.URL)
+	}
+
+	if errors.Unwrap(err) == nil {
+		t.Error("Expected the underlying error to be unwrappable")
+	}
+}
+
+func TestRootFetchErrorOnlyForTransportFailures(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	closed, _ := createTestHttpServer()
+	closed.Close()
+
+	_, err := Navigator(closed.URL).Get()
+	if _, ok := err.(RootFetchError); !ok {
+		t.Errorf("Expected RootFetchError from a direct Get, got %v", err)
+	}
+
+	_, err = Navigator(ts.URL + "/broken").CheckStatus(true).Follow("next").Get()
+	if errors.As(err, new(RootFetchError)) {
+		t.Errorf("Expected an error response not to be a RootFetchError, got %v", err)
+	}
+
+	_, err = Navigator(ts.URL + "/2nd").Follow("next").Get()
+	if errors.As(err, new(RootFetchError)) {
+		t.Errorf("Expected an unparseable root not to be a RootFetchError, got %v", err)
+	}
+
+	_, err = Navigator(ts.URL).FollowURL(closed.URL).Follow("next").Get()
+	if errors.As(err, new(RootFetchError)) {
+		t.Errorf("Expected a failure after FollowURL not to be a RootFetchError, got %v", err)
+	}
+
+	client := &MockHttpClient{}
+	client.Respond("http://api.example.com/", 200, `{ "_links": { "next": { "href": "/2" } } }`)
+	client.RespondFunc("http://api.example.com/2", func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection reset")
+	})
+
+	pages := 0
+	err = Navigator("http://api.example.com/").WithClient(client).EachPage(func(*http.Response) error {
+		pages++
+		return nil
+	})
+	if err == nil || errors.As(err, new(RootFetchError)) {
+		t.Errorf("Expected a failure fetching a later page not to be a RootFetchError, got %v", err)
+	}
+	if pages != 1 {
+		t.Errorf("Expected the first page to be read, got %d pages", pages)
+	}
+}
+
+func TestFollowProfile(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	res, err := Navigator(ts.URL).FollowProfile("profiled", "http://example.com/profiles/v2").Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Request.URL.String() != ts.URL+"/a/2" {
+		t.Errorf("Expected url to be %s, got %s", ts.URL+"/a/2", res.Request.URL)
+	}
+
+	_, err = Navigator(ts.URL).FollowProfile("profiled", "http://example.com/profiles/v3").Get()
+	if _, ok := err.(NoMatchingLinkError); !ok {
+		t.Errorf("Expected NoMatchingLinkError, got %v", err)
+	}
+}
+
+func TestGetWithBody(t *testing.T) {
+	var method, contentType, body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		method, contentType, body = r.Method, r.Header.Get("Content-Type"), string(b)
+	}))
+	defer ts.Close()
+
+	_, err := Navigator(ts.URL).GetWithBody("application/json", strings.NewReader(`{"q":"test"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if method != "GET" {
+		t.Errorf("Expected GET, got %s", method)
+	}
+
+	if contentType != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %s", contentType)
+	}
+
+	if body != `{"q":"test"}` {
+		t.Errorf("Expected body to be sent, got %s", body)
+	}
+}
+
+func TestGetWithBodyHeaders(t *testing.T) {
+	headers := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get("X-Query")+" "+r.Header.Get("X-Api-Version"))
+		fmt.Fprint(w, `{ "_links": { "search": { "href": "/search" } } }`)
+	}))
+	defer ts.Close()
+
+	_, err := Navigator(ts.URL).
+		AddHeaders(map[string]string{"X-Api-Version": "1"}).
+		Follow("search").
+		GetWithBody("application/json", strings.NewReader(`{}`),
+			http.Header{"X-Query": {"jmespath"}},
+			http.Header{"X-Api-Version": {"2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{" 1", "jmespath 2"}
+	if fmt.Sprint(headers) != fmt.Sprint(expected) {
+		t.Errorf("Expected headers only on the tip %q, got %q", expected, headers)
+	}
+}
+
+func TestFollowingAnEmptyHref(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	_, err := Navigator(ts.URL).Follow("empty").Get()
+	if _, ok := err.(InvalidUrlError); !ok {
+		t.Errorf("Expected InvalidUrlError by default, got %v", err)
+	}
+
+	res, err := Navigator(ts.URL).Follow("self").EmptyHrefIsSelf(true).Follow("empty").Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Request.URL.String() != ts.URL+"/" {
+		t.Errorf("Expected url to be %s, got %s", ts.URL+"/", res.Request.URL)
+	}
+
+	if hits["/"] != 4 {
+		t.Errorf("Expected 4 requests to /, got %d", hits["/"])
+	}
+}
+
+func TestNavigatorRels(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	rels, err := Navigator(ts.URL).Follow("child").Rels()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(rels) != "[parent]" {
+		t.Errorf("Expected [parent], got %v", rels)
+	}
+}
+
+func TestFollowfCopiesParams(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	params := P{"id": 1}
+	nav := Navigator(ts.URL).Followf("one", params)
+	params["id"] = 2
+
+	url, err := nav.url()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if url != ts.URL+"/a/1" {
+		t.Errorf("Expected url to be %s, got %s", ts.URL+"/a/1", url)
+	}
+}
+
+func TestFollowfStrict(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	url, err := Navigator(ts.URL).FollowfStrict("one", P{"id": 1}).url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != ts.URL+"/a/1" {
+		t.Errorf("Expected url to be %s, got %s", ts.URL+"/a/1", url)
+	}
+
+	_, err = Navigator(ts.URL).FollowfStrict("one", P{"di": 1}).url()
+	if err == nil || err.Error() != "Parameters for 'one' didn't match its template: unknown [di], missing [id]" {
+		t.Errorf("Expected TemplateParamsError, got %v", err)
+	}
+
+	_, err = Navigator(ts.URL).FollowfStrict("one", nil).url()
+	if _, ok := err.(TemplateParamsError); !ok {
+		t.Errorf("Expected TemplateParamsError, got %v", err)
+	}
+}
+
+func TestPing(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	if err := Navigator(ts.URL).Ping("child", "parent"); err != nil {
+		t.Errorf("Expected ping to succeed, got %v", err)
+	}
+
+	err := Navigator(ts.URL).Ping("broken")
+	if err == nil || err.Error() != "Ping of "+ts.URL+"/broken failed: 500 Internal Server Error" {
+		t.Errorf("Expected ping to fail with status, got %v", err)
+	}
+
+	err = Navigator(ts.URL).Ping("missing")
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Errorf("Expected LinkNotFoundError, got %v", err)
+	}
+}
+
+func TestPingWithoutResponseRequest(t *testing.T) {
+	client := &MockHttpClient{}
+	client.Respond("http://api.example.com/", 200, `{ "_links": { "orders": { "href": "/orders" } } }`)
+	client.RespondFunc("http://api.example.com/orders", func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 503, Status: "503 Service Unavailable", Body: http.NoBody}, nil
+	})
+
+	err := Navigator("http://api.example.com/").WithClient(client).Ping("orders")
+	if expected := "Ping of http://api.example.com/ -> orders failed: 503 Service Unavailable"; err == nil || err.Error() != expected {
+		t.Errorf("Expected %s, got %v", expected, err)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	nav := Navigator(ts.URL).WithTimeout(20 * time.Millisecond)
+
+	_, err := nav.Follow("slow").Follow("next").Get()
+	timeoutErr, ok := err.(FollowTimeoutError)
+	if !ok {
+		t.Fatalf("Expected FollowTimeoutError, got %v", err)
+	}
+	if timeoutErr.Rel != "next" || timeoutErr.URL != ts.URL+"/delay/50" {
+		t.Errorf("Expected timeout following next from /delay/50, got %v", timeoutErr)
+	}
+
+	if _, err = nav.Follow("slow").Get(); err == nil {
+		t.Error("Expected the final request to time out")
+	}
+
+	res, err := nav.Follow("fast").Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if body, err := ioutil.ReadAll(res.Body); err != nil || string(body) != `{ "delay": 0 }` {
+		t.Errorf("Expected body to be readable, got %s (%v)", body, err)
+	}
+}
+
+func TestUnmarshalResponse(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	var page struct{ Page int }
+	res, err := Navigator(ts.URL).Follow("pages").UnmarshalResponse(&page)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page.Page != 1 {
+		t.Errorf("Expected page to be 1, got %d", page.Page)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Expected OK, got %d", res.StatusCode)
+	}
+
+	if ct := res.Header.Get("Content-Type"); ct == "" {
+		t.Error("Expected response headers to be available")
+	}
+}
+
+func TestGetBody(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	body, res, err := Navigator(ts.URL).Follow("child").GetBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `{ "_links": { "parent": { "href": "/" } } }`; string(body) != expected {
+		t.Errorf("Expected %s, got %s", expected, body)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Expected OK, got %d", res.StatusCode)
+	}
+}
+
+func TestUnmarshalEmptyBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/no-content" {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	for _, path := range []string{"/no-content", "/empty"} {
+		page := struct{ Page int }{Page: 1}
+		if err := Navigator(ts.URL + path).Unmarshal(&page); err != nil {
+			t.Errorf("Expected no error for %s, got %v", path, err)
+		}
+
+		if page.Page != 1 {
+			t.Errorf("Expected %s to leave v unchanged, got %d", path, page.Page)
+		}
+	}
+}
+
+func TestAccept(t *testing.T) {
+	accepts := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepts = append(accepts, r.Header.Get("Accept"))
+		fmt.Fprint(w, `{ "_links": { "self": { "href": "/" } } }`)
+	}))
+	defer ts.Close()
+
+	base := Navigator(ts.URL)
+
+	if _, err := base.Accept("application/ld+json", "application/hal+json").Follow("self").Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := base.Accept("application/ld+json").AcceptHalForms().Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := base.Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"application/ld+json, application/hal+json",
+		"application/ld+json, application/hal+json",
+		"application/prs.hal-forms+json, application/ld+json",
+		"application/hal+json, application/json",
+	}
+	if fmt.Sprint(accepts) != fmt.Sprint(expected) {
+		t.Errorf("Expected Accept headers %q, got %q", expected, accepts)
+	}
+}
+
+func TestNoDefaultAccept(t *testing.T) {
+	accepts := [][]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepts = append(accepts, r.Header["Accept"])
+		fmt.Fprint(w, `{ "_links": { "self": { "href": "/" } } }`)
+	}))
+	defer ts.Close()
+
+	if _, err := Navigator(ts.URL).NoDefaultAccept().Follow("self").Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Navigator(ts.URL).AddHeaders(map[string]string{"Accept": "text/plain"}).Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Navigator(ts.URL).NoDefaultAccept().AcceptHalForms().Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{nil, nil, {"text/plain"}, {"application/prs.hal-forms+json"}}
+	if fmt.Sprint(accepts) != fmt.Sprint(expected) {
+		t.Errorf("Expected Accept headers %q, got %q", expected, accepts)
+	}
+}
+
+func TestFollowingFromANonJSONDocument(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprint(w, "<html><body>"+strings.Repeat("Bad Gateway ", 100)+"</body></html>")
+	}))
+	defer ts.Close()
+
+	_, err := Navigator(ts.URL).Follow("next").Follow("next").Get()
+
+	var contentTypeErr UnexpectedContentTypeError
+	if !errors.As(err, &contentTypeErr) {
+		t.Fatalf("Expected UnexpectedContentTypeError, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "'text/html' (502 Bad Gateway): <html><body>Bad Gateway") {
+		t.Errorf("Expected error to describe the response, got %s", err)
+	}
+
+	if !strings.HasSuffix(err.Error(), "...") || len(err.Error()) > 400 {
+		t.Errorf("Expected the body to be truncated, got %s", err)
+	}
+}
+
+func TestPagingAndNavFromANonJSONDocument(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><body>"+strings.Repeat("Maintenance ", 100)+"</body></html>")
+	}))
+	defer ts.Close()
+
+	err := Navigator(ts.URL).EachPage(func(*http.Response) error { return nil })
+	if _, ok := err.(UnexpectedContentTypeError); !ok || len(err.Error()) > 400 {
+		t.Errorf("Expected a truncated UnexpectedContentTypeError from EachPage, got %v", err)
+	}
+
+	res, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NavFromResponse(res, "")
+	if _, ok := err.(UnexpectedContentTypeError); !ok || len(err.Error()) > 400 {
+		t.Errorf("Expected a truncated UnexpectedContentTypeError from NavFromResponse, got %v", err)
+	}
+}
+
+func TestFollowAll(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	res, err := Navigator(ts.URL).WithConcurrency(1).FollowAll("slow", "fast", "child")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for rel, path := range map[string]string{"slow": "/delay/50", "fast": "/delay/0", "child": "/child"} {
+		if res[rel] == nil || res[rel].Request.URL.Path != path {
+			t.Errorf("Expected %s response to be for %s, got %v", rel, path, res[rel])
+		}
+	}
+
+	if hits["/"] != 1 {
+		t.Errorf("Expected 1 request to /, got %d", hits["/"])
+	}
+
+	res, err = Navigator(ts.URL).FollowAll("fast", "missing")
+	if _, ok := err.(ParallelError); !ok {
+		t.Errorf("Expected ParallelError, got %v", err)
+	}
+
+	if _, ok := res["missing"]; ok || res["fast"] == nil {
+		t.Errorf("Expected only the successful response, got %v", res)
+	}
+}
+
+func TestAddHeadersAreSentWithEveryRequest(t *testing.T) {
+	headers := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Method+" "+r.Header.Get("X-A")+" "+r.Header.Get("X-B")+" "+r.Header.Get("Accept"))
+		fmt.Fprint(w, `{ "_links": { "self": { "href": "/" } } }`)
+	}))
+	defer ts.Close()
+
+	nav := Navigator(ts.URL).
+		AddHeaders(map[string]string{"X-A": "1", "X-B": "1"}).
+		AddHeaders(map[string]string{"X-B": "2", "Accept": "text/plain"})
+
+	if _, err := nav.Follow("self").Post("text/plain", strings.NewReader("")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"GET 1 2 text/plain", "POST 1 2 text/plain"}
+	if fmt.Sprint(headers) != fmt.Sprint(expected) {
+		t.Errorf("Expected headers %q, got %q", expected, headers)
+	}
+}
+
+func TestAddHeadersDoesNotLeakAcrossChains(t *testing.T) {
+	headers := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers[r.URL.Path] = r.Header.Get("X-A") + "," + r.Header.Get("X-B")
+		fmt.Fprint(w, `{ "_links": { "a": { "href": "/a" }, "b": { "href": "/b" }, "c": { "href": "/base" } } }`)
+	}))
+	defer ts.Close()
+
+	base := Navigator(ts.URL).AddHeaders(map[string]string{"X-A": "base"})
+	a := base.AddHeaders(map[string]string{"X-B": "a"})
+	b := base.AddHeaders(map[string]string{"X-A": "b"})
+
+	if _, err := a.Follow("a").Get(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Follow("b").Get(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := base.Follow("c").Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	if headers["/a"] != "base,a" {
+		t.Errorf("Expected headers base,a on first chain, got %s", headers["/a"])
+	}
+	if headers["/b"] != "b," {
+		t.Errorf("Expected headers b, on second chain, got %s", headers["/b"])
+	}
+	if headers["/base"] != "base," {
+		t.Errorf("Expected headers base, on base chain, got %s", headers["/base"])
+	}
+}
+
+func TestWithObserverSeesEveryRequest(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	observed := []string{}
+	_, err := Navigator(ts.URL).
+		WithObserver(func(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+			if err != nil {
+				t.Errorf("Expected no error observed, got %v", err)
+				return
+			}
+			if elapsed < 0 {
+				t.Errorf("Expected a positive elapsed time, got %v", elapsed)
+			}
+			observed = append(observed, fmt.Sprintf("%s %s %d", req.Method, req.URL.Path, res.StatusCode))
+		}).
+		Follow("next").
+		Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"GET  200", "GET /2nd 200"}
+	if fmt.Sprint(observed) != fmt.Sprint(expected) {
+		t.Errorf("Expected observed requests %q, got %q", expected, observed)
+	}
+}
+
+func TestRelativeLinksResolveAgainstTheDocumentTheyAppearIn(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/", http.RedirectHandler("/v2/", http.StatusFound))
+	r.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{ "_links": { "products": { "href": "products/" } } }`)
+	})
+	r.HandleFunc("/v2/products/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{ "_links": { "first": { "href": "1" }, "up": { "href": "../" } } }`)
+	})
+	r.HandleFunc("/v2/products/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "first")
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	url, err := Navigator(ts.URL + "/").Follow("products").Follow("first").url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != ts.URL+"/v2/products/1" {
+		t.Errorf("Expected url %s/v2/products/1, got %s", ts.URL, url)
+	}
+
+	url, err = Navigator(ts.URL + "/").Follow("products").Follow("up").url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != ts.URL+"/v2/" {
+		t.Errorf("Expected url %s/v2/, got %s", ts.URL, url)
+	}
+}
+
+func TestFinalURLFollowsRedirects(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{ "_links": { "moved": { "href": "/old" }, "stays": { "href": "/new" } } }`)
+	})
+	r.Handle("/old", http.RedirectHandler("/new", http.StatusMovedPermanently))
+	r.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	url, err := Navigator(ts.URL).Follow("moved").FinalURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != ts.URL+"/new" {
+		t.Errorf("Expected final url %s/new, got %s", ts.URL, url)
+	}
+
+	url, err = Navigator(ts.URL).Follow("stays").FinalURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != ts.URL+"/new" {
+		t.Errorf("Expected final url %s/new, got %s", ts.URL, url)
+	}
+}
+
+func TestCheckStatusReturnsHTTPError(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	res, err := Navigator(ts.URL).Follow("broken").Get()
+	if err != nil || res.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected a 500 response without CheckStatus, got %v, %v", res, err)
+	}
+
+	_, err = Navigator(ts.URL).CheckStatus(true).Follow("broken").Get()
+
+	var httpErr HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Expected HTTPError, got %v", err)
+	}
+	if httpErr.StatusCode != http.StatusInternalServerError || httpErr.URL != ts.URL+"/broken" {
+		t.Errorf("Expected 500 from %s/broken, got %d from %s", ts.URL, httpErr.StatusCode, httpErr.URL)
+	}
+
+	var problem ProblemDetails
+	if errors.As(err, &problem) {
+		t.Errorf("Expected no ProblemDetails for a plain error response, got %v", problem)
+	}
+}
+
+func TestCheckStatusParsesProblemDetails(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{ "_links": { "orders": { "href": "/orders" } } }`)
+	})
+	r.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{
+      "type": "https://example.com/probs/out-of-credit",
+      "title": "You do not have enough credit.",
+      "status": 403,
+      "detail": "Your current balance is 30, but that costs 50.",
+      "instance": "/account/12345/msgs/abc"
+    }`)
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	// the failing request is an intermediate one, not the tip
+	_, err := Navigator(ts.URL).CheckStatus(true).Follow("orders").Follow("next").Get()
+
+	var problem ProblemDetails
+	if !errors.As(err, &problem) {
+		t.Fatalf("Expected ProblemDetails, got %v", err)
+	}
+	if problem.Type != "https://example.com/probs/out-of-credit" || problem.Status != 403 ||
+		problem.Detail != "Your current balance is 30, but that costs 50." || problem.Instance != "/account/12345/msgs/abc" {
+		t.Errorf("Unexpected problem details %+v", problem)
+	}
+
+	var httpErr HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected ProblemDetails to wrap a 403 HTTPError, got %v", httpErr)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	conditions := []string{}
+	r := mux.NewRouter()
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		conditions = append(conditions, r.Header.Get("If-Match")+r.Header.Get("If-None-Match"))
+		fmt.Fprint(w, `{ "_links": { "order": { "href": "/order" } } }`)
+	})
+	r.HandleFunc("/order", func(w http.ResponseWriter, r *http.Request) {
+		conditions = append(conditions, r.Header.Get("If-Match")+r.Header.Get("If-None-Match"))
+		if match := r.Header.Get("If-Match"); match != "" && match != `"v2"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+		}
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	order := Navigator(ts.URL).CheckStatus(true).Follow("order")
+
+	if _, err := order.IfMatch(`"v2"`).Put("text/plain", strings.NewReader("")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := order.IfNoneMatch("*").Put("text/plain", strings.NewReader("")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"", `"v2"`, "", "*"}
+	if fmt.Sprint(conditions) != fmt.Sprint(expected) {
+		t.Errorf("Expected conditions only on the tip %q, got %q", expected, conditions)
+	}
+
+	_, err := order.IfMatch(`"v1"`).Put("text/plain", strings.NewReader(""))
+
+	var failed PreconditionFailedError
+	if !errors.As(err, &failed) {
+		t.Fatalf("Expected PreconditionFailedError, got %v", err)
+	}
+
+	var httpErr HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("Expected PreconditionFailedError to wrap a 412 HTTPError, got %v", httpErr)
+	}
+}
+
+func TestConditionsOnlyOnTheTerminalWrite(t *testing.T) {
+	requests := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("If-None-Match"))
+		if r.Method != "GET" {
+			return
+		}
+		fmt.Fprint(w, `{
+      "_links": { "self": { "href": "/order" }, "order": { "href": "/order" } },
+      "_templates": { "default": { "method": "put", "properties": [] } }
+    }`)
+	}))
+	defer ts.Close()
+
+	order := Navigator(ts.URL).Follow("order").IfNoneMatch(`"v1"`)
+	values := map[string]interface{}{}
+
+	writes := map[string]func() (*http.Response, error){
+		"Put":    func() (*http.Response, error) { return order.Put("text/plain", strings.NewReader("")) },
+		"Post":   func() (*http.Response, error) { return order.Post("text/plain", strings.NewReader("")) },
+		"Patch":  func() (*http.Response, error) { return order.Patch("text/plain", strings.NewReader("")) },
+		"Delete": order.Delete,
+		"SubmitForm": func() (*http.Response, error) {
+			return order.SubmitForm("default", values)
+		},
+		"FollowForm": func() (*http.Response, error) {
+			return Navigator(ts.URL).IfNoneMatch(`"v1"`).FollowForm("order", "default", values)
+		},
+		"Update": func() (*http.Response, error) {
+			var v map[string]interface{}
+			return order.Update(&v, func(interface{}) error { return nil })
+		},
+	}
+
+	for name, write := range writes {
+		requests = requests[:0]
+
+		res, err := write()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		res.Body.Close()
+
+		last := len(requests) - 1
+		for i, request := range requests {
+			conditional := strings.HasSuffix(request, `"v1"`)
+			if conditional != (i == last) {
+				t.Errorf("%s: Expected the condition only on the write, got %q", name, requests)
+				break
+			}
+		}
+	}
+}
+
+func TestWithLinkSelector(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	selected := ""
+	_, err := Navigator(ts.URL).
+		WithLinkSelector(func(rel string, set LinkSet) (Link, error) {
+			selected = rel
+			return set[len(set)-1], nil
+		}).
+		Follow("profiled").
+		Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if selected != "profiled" {
+		t.Errorf("Expected selector to be called for profiled, got %s", selected)
+	}
+	if hits["/a/2"] != 1 || hits["/a/1"] != 0 {
+		t.Errorf("Expected the last profiled link to be followed, got %v", hits)
+	}
+
+	_, err = Navigator(ts.URL).
+		WithLinkSelector(func(rel string, set LinkSet) (Link, error) {
+			return Link{}, errors.New("no suitable link")
+		}).
+		Follow("profiled").
+		Get()
+	if err == nil || err.Error() != "no suitable link" {
+		t.Errorf("Expected selector error, got %v", err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{ "_links": { "items": { "href": "/items" }, "self": { "href": "/" } } }`)
+	})
+	r.HandleFunc("/items", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[ { "id": 1 }, { "id": 2 }, { "id": 3 } ]`)
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	var item struct{ ID int }
+	ids := []int{}
+	err := Navigator(ts.URL).Follow("items").Stream(&item, func() error {
+		ids = append(ids, item.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids) != "[1 2 3]" {
+		t.Errorf("Expected ids [1 2 3], got %v", ids)
+	}
+
+	stop := errors.New("stop")
+	ids = []int{}
+	err = Navigator(ts.URL).Follow("items").Stream(&item, func() error {
+		ids = append(ids, item.ID)
+		return stop
+	})
+	if err != stop || len(ids) != 1 {
+		t.Errorf("Expected stream to stop after one item, got %v, %v", ids, err)
+	}
+
+	err = Navigator(ts.URL).Follow("self").Stream(&item, func() error { return nil })
+	if err == nil || !strings.HasPrefix(err.Error(), "Expected a JSON array") {
+		t.Errorf("Expected an error streaming a non-array, got %v", err)
+	}
+}
+
+func TestRequestCounter(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	var counter RequestCounter
+	nav := Navigator(ts.URL).WithObserver(counter.Observe)
+
+	if _, err := nav.Follow("child").Follow("parent").Get(); err != nil {
+		t.Fatal(err)
+	}
+	if counter.Count() != 3 {
+		t.Errorf("Expected 3 requests, got %d", counter.Count())
+	}
+
+	counter.Reset()
+	if _, err := nav.FollowParallel("next", "child", "fast"); err != nil {
+		t.Fatal(err)
+	}
+	if counter.Count() != 4 {
+		t.Errorf("Expected 4 requests, got %d", counter.Count())
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	var method, ifMatch, body string
+	r := mux.NewRouter()
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{ "_links": { "product": { "href": "/products/latest" } } }`)
+	})
+	r.HandleFunc("/products/latest", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{ "_links": { "self": { "href": "/products/1" } }, "price": 5 }`)
+	})
+	r.HandleFunc("/products/1", func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		ifMatch = r.Header.Get("If-Match")
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	var product struct {
+		Price int `json:"price"`
+	}
+	_, err := Navigator(ts.URL).Follow("product").Update(&product, func(v interface{}) error {
+		product.Price = 10
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if method != "PUT" {
+		t.Errorf("Expected PUT to self, got %s", method)
+	}
+	if ifMatch != `"v1"` {
+		t.Errorf("Expected If-Match \"v1\", got %s", ifMatch)
+	}
+	if body != `{"price":10}` {
+		t.Errorf("Expected modified body, got %s", body)
+	}
+
+	method = ""
+	abort := errors.New("abort")
+	_, err = Navigator(ts.URL).Follow("product").Update(&product, func(v interface{}) error {
+		return abort
+	})
+	if err != abort || method != "" {
+		t.Errorf("Expected mutate error and no PUT, got %v, %s", err, method)
+	}
+}
+
+func TestFollowURL(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	_, err := Navigator(ts.URL).FollowURL("/child").Follow("parent").Follow("next").Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hits["/child"] != 1 || hits["/"] != 1 || hits["/2nd"] != 1 {
+		t.Errorf("Expected child, root and 2nd to be requested once each, got %v", hits)
+	}
+
+	url, err := Navigator(ts.URL).Follow("child").FollowURL(ts.URL + "/a/3").url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != ts.URL+"/a/3" {
+		t.Errorf("Expected url %s/a/3, got %s", ts.URL, url)
+	}
+	if hits["/child"] != 1 {
+		t.Errorf("Expected child not to be requested before following a URL, got %d", hits["/child"])
+	}
+}
+
+func TestFollowMissingAndEmptyRelations(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{ "_links": { "items": [] } }`)
+	}))
+	defer ts.Close()
+
+	_, err := Navigator(ts.URL).Follow("missing").Get()
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Errorf("Expected LinkNotFoundError, got %v", err)
+	}
+
+	_, err = Navigator(ts.URL).Follow("items").Get()
+	if _, ok := err.(EmptyLinkSetError); !ok {
+		t.Errorf("Expected EmptyLinkSetError, got %v", err)
+	}
+
+	_, err = Navigator(ts.URL).FollowProfile("items", "http://example.com/profiles/v1").Get()
+	if _, ok := err.(EmptyLinkSetError); !ok {
+		t.Errorf("Expected EmptyLinkSetError, got %v", err)
+	}
+}
+
+func TestFollowFromDocumentWithoutLinks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/empty" {
+			fmt.Fprint(w, `{ "_links": {} }`)
+			return
+		}
+		fmt.Fprint(w, `{ "name": "not HAL" }`)
+	}))
+	defer ts.Close()
+
+	_, err := Navigator(ts.URL).Follow("next").Get()
+	if err == nil || err.Error() != "Document at "+ts.URL+" ('application/json') has no _links object" {
+		t.Errorf("Expected NoLinksError, got %v", err)
+	}
+
+	_, err = Navigator(ts.URL + "/empty").Follow("next").Get()
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Errorf("Expected LinkNotFoundError from empty _links, got %v", err)
+	}
+}
+
+func TestFollowLang(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{ "_links": { "help": [
+      { "href": "/help/en", "hreflang": "en" },
+      { "href": "/help/de", "hreflang": "de" }
+    ] } }`)
+	}))
+	defer ts.Close()
+
+	url, err := Navigator(ts.URL).FollowLang("help", "DE").url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != ts.URL+"/help/de" {
+		t.Errorf("Expected url %s/help/de, got %s", ts.URL, url)
+	}
+
+	_, err = Navigator(ts.URL).FollowLang("help", "fr").url()
+	if _, ok := err.(NoMatchingLinkError); !ok {
+		t.Errorf("Expected NoMatchingLinkError, got %v", err)
+	}
+
+	url, err = Navigator(ts.URL).LangFallback(true).FollowLang("help", "fr").url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != ts.URL+"/help/en" {
+		t.Errorf("Expected fallback url %s/help/en, got %s", ts.URL, url)
+	}
+}
+
+func TestWithMaxDepth(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	nav := Navigator(ts.URL).WithMaxDepth(2)
+
+	_, err := nav.Follow("child").Follow("parent").Follow("next").Get()
+	if _, ok := err.(MaxDepthExceededError); !ok {
+		t.Errorf("Expected MaxDepthExceededError, got %v", err)
+	}
+	if len(hits) != 0 {
+		t.Errorf("Expected no requests when the queue is too long, got %v", hits)
+	}
+
+	if _, err := nav.Follow("child").Follow("parent").Get(); err != nil {
+		t.Errorf("Expected no error within the maximum depth, got %v", err)
+	}
+
+	pages := 0
+	err = nav.Follow("pages").EachPage(func(res *http.Response) error {
+		pages++
+		return nil
+	})
+	if _, ok := err.(MaxDepthExceededError); !ok {
+		t.Errorf("Expected MaxDepthExceededError from EachPage, got %v", err)
+	}
+	if pages != 2 {
+		t.Errorf("Expected 2 pages before the maximum depth, got %d", pages)
+	}
+}
+
+func TestWithMaxDepthCountsOnlyRelations(t *testing.T) {
+	client := &MockHttpClient{}
+	client.Respond("http://api.example.com/orders/1", 200, `{
+    "_embedded": {
+      "customer": {
+        "_links": { "address": { "href": "/addresses/1" } }
+      }
+    }
+  }`)
+	client.Respond("http://api.example.com/addresses/1", 200, `{
+    "_links": { "country": { "href": "/countries/nz" } }
+  }`)
+
+	nav := Navigator("http://api.example.com/").
+		WithClient(client).
+		WithMaxDepth(2)
+
+	url, err := nav.FollowURL("/orders/1").
+		InEmbedded("customer").
+		Follow("address").
+		Follow("country").
+		url()
+	if err != nil {
+		t.Errorf("Expected InEmbedded and FollowURL not to count towards the maximum depth, got %v", err)
+	}
+	if url != "http://api.example.com/countries/nz" {
+		t.Errorf("Expected http://api.example.com/countries/nz, got %s", url)
+	}
+
+	_, err = nav.FollowURL("/orders/1").
+		InEmbedded("customer").
+		Follow("address").
+		Follow("country").
+		Follow("capital").
+		url()
+	if _, ok := err.(MaxDepthExceededError); !ok {
+		t.Errorf("Expected MaxDepthExceededError, got %v", err)
+	}
+}
+
+func TestEachPageDetectsCycles(t *testing.T) {
+	hits := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		// every page, including /2 itself, says /2 is next
+		fmt.Fprint(w, `{ "_links": { "next": { "href": "/2" } } }`)
+	}))
+	defer ts.Close()
+
+	pages := 0
+	count := func(res *http.Response) error {
+		pages++
+		return nil
+	}
+
+	err := Navigator(ts.URL + "/1").EachPage(count)
+	if _, ok := err.(PageCycleError); !ok {
+		t.Errorf("Expected PageCycleError, got %v", err)
+	}
+	if pages != 2 || hits["/2"] != 1 {
+		t.Errorf("Expected 2 pages with /2 requested once, got %d pages and %v", pages, hits)
+	}
+
+	pages = 0
+	if err := Navigator(ts.URL + "/1").EndPagingOnCycle(true).EachPage(count); err != nil {
+		t.Errorf("Expected paging to end cleanly, got %v", err)
+	}
+	if pages != 2 {
+		t.Errorf("Expected 2 pages, got %d", pages)
+	}
+}
+
+func TestWithTemplateExpander(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	hrefs := []string{}
+	_, err := Navigator(ts.URL).
+		WithTemplateExpander(func(href string, params P) (string, error) {
+			hrefs = append(hrefs, href)
+			return strings.Replace(href, "{id}", "custom", 1), nil
+		}).
+		Followf("one", P{"id": 1}).
+		Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hrefs) != 1 || !strings.HasSuffix(hrefs[0], "/a/{id}") {
+		t.Errorf("Expected the expander to be given the template, got %v", hrefs)
+	}
+	if hits["/a/custom"] != 1 {
+		t.Errorf("Expected the custom expansion to be followed, got %v", hits)
+	}
+}
+
+func TestFollowQuery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{ "_links": { "plain": { "href": "/products" }, "filtered": { "href": "/products?colour=blue&size=10" } } }`)
+	}))
+	defer ts.Close()
+
+	href, err := Navigator(ts.URL).FollowQuery("plain", url.Values{"colour": {"red"}}).url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if href != ts.URL+"/products?colour=red" {
+		t.Errorf("Expected query to be added, got %s", href)
+	}
+
+	href, err = Navigator(ts.URL).FollowQuery("filtered", url.Values{"colour": {"red", "green"}}).url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if href != ts.URL+"/products?colour=red&colour=green&size=10" {
+		t.Errorf("Expected query to be merged, got %s", href)
+	}
+}
+
+func TestBaseNavigatorIsSafeToShareAcrossGoroutines(t *testing.T) {
+	var mu sync.Mutex
+	workers := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/worker" {
+			mu.Lock()
+			workers[r.URL.Query().Get("id")] = r.Header.Get("X-Worker") + " " + r.Header.Get("X-Base")
+			mu.Unlock()
+		}
+		fmt.Fprint(w, `{ "_links": { "worker": { "href": "/worker{?id}" } } }`)
+	}))
+	defer ts.Close()
+
+	base := Navigator(ts.URL).
+		AddHeaders(map[string]string{"X-Base": "base"}).
+		Accept("application/hal+json").
+		Follow("worker")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+
+			_, err := base.
+				AddHeaders(map[string]string{"X-Worker": id}).
+				Followf("worker", P{"id": id}).
+				Get()
+			if err != nil {
+				t.Error(err)
+			}
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	for i := 0; i < 20; i++ {
+		id := strconv.Itoa(i)
+		if workers[id] != id+" base" {
+			t.Errorf("Expected worker %s to send its own headers, got %q", id, workers[id])
+		}
+	}
+}
+
+type countingHttpClient struct {
+	count *int
+}
+
+func (c countingHttpClient) Do(req *http.Request) (*http.Response, error) {
+	*c.count++
+	return http.DefaultClient.Do(req)
+}
+
+func TestFollowMethodsKeepConfiguration(t *testing.T) {
+	received := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tip" {
+			received = r.Header.Get("X-Test")
+		}
+		fmt.Fprint(w, `{ "_links": { "tip": { "href": "/tip{?id}", "profile": "p", "hreflang": "en" } } }`)
+	}))
+	defer ts.Close()
+
+	count := 0
+	base := Navigator(ts.URL).AddHeaders(map[string]string{"X-Test": "kept"})
+	base.HttpClient = countingHttpClient{&count}
+
+	follows := map[string]navigator{
+		"Follow":        base.Follow("tip"),
+		"Followf":       base.Followf("tip", P{"id": 1}),
+		"FollowfStrict": base.FollowfStrict("tip", P{"id": 1}),
+		"FollowProfile": base.FollowProfile("tip", "p"),
+		"FollowLang":    base.FollowLang("tip", "en"),
+		"FollowQuery":   base.FollowQuery("tip", url.Values{"id": {"1"}}),
+		"FollowURL":     base.FollowURL("/tip"),
+	}
+
+	for name, nav := range follows {
+		received, count = "", 0
+
+		if _, err := nav.Get(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if received != "kept" {
+			t.Errorf("Expected %s to keep headers, got %q", name, received)
+		}
+		if count == 0 {
+			t.Errorf("Expected %s to keep the HttpClient", name)
+		}
+	}
+}
+
+// seekingReader hides the concrete type of a strings.Reader, so the body
+// is only known to be an io.ReadSeeker.
+type seekingReader struct {
+	io.ReadSeeker
+}
+
+func TestPostSized(t *testing.T) {
+	var length int64
+	var encoding []string
+	var body []byte
+	var checksum string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		length, encoding = r.ContentLength, r.TransferEncoding
+		body, _ = ioutil.ReadAll(r.Body)
+		checksum = r.Header.Get("Content-MD5")
+	}))
+	defer ts.Close()
+
+	// a MultiReader hides the length of the strings.Reader from net/http
+	content := io.MultiReader(strings.NewReader("hello world"))
+
+	_, err := Navigator(ts.URL).PostSized("text/plain", content, 11,
+		http.Header{"Content-MD5": {"XrY7u+Ae7tCTyyK7j1rNww=="}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if checksum != "XrY7u+Ae7tCTyyK7j1rNww==" {
+		t.Errorf("Expected the per-request header to be sent, got %q", checksum)
+	}
+
+	if length != 11 || len(encoding) != 0 {
+		t.Errorf("Expected Content-Length of 11 and no transfer encoding, got %d and %v", length, encoding)
+	}
+
+	if string(body) != "hello world" {
+		t.Errorf("Expected body to be sent, got %s", body)
+	}
+}
+
+func TestRedirectedPostResendsBody(t *testing.T) {
+	received := []string{}
+	r := mux.NewRouter()
+	r.Handle("/old", http.RedirectHandler("/new", http.StatusTemporaryRedirect))
+	r.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		received = append(received, fmt.Sprintf("%s %d %s", r.Method, r.ContentLength, b))
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	bodies := []io.Reader{
+		strings.NewReader("plain"),
+		io.MultiReader(strings.NewReader("buff"), strings.NewReader("ered")),
+		seekingReader{strings.NewReader("seeker")},
+	}
+
+	for _, body := range bodies {
+		if _, err := Navigator(ts.URL+"/old").Post("text/plain", body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []string{"POST 5 plain", "POST 8 buffered", "POST 6 seeker"}
+	if fmt.Sprint(received) != fmt.Sprint(expected) {
+		t.Errorf("Expected redirected bodies %q, got %q", expected, received)
+	}
+}
+
+func TestInEmbedded(t *testing.T) {
+	hits := map[string]int{}
+	r := mux.NewRouter()
+	r.HandleFunc("/orders/1", func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		fmt.Fprint(w, `{
+      "_links": { "self": { "href": "/orders/1" } },
+      "_embedded": {
+        "customer": {
+          "_links": { "self": { "href": "/customers/1" }, "address": { "href": "addresses/1" } }
+        },
+        "items": [
+          { "_links": { "product": { "href": "/products/7" } } },
+          { "_links": { "product": { "href": "/products/8" } } }
+        ]
+      }
+    }`)
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	nav := Navigator(ts.URL + "/orders/1")
+
+	href, err := nav.InEmbedded("customer").Follow("address").url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if href != ts.URL+"/orders/addresses/1" {
+		t.Errorf("Expected address resolved against the order, got %s", href)
+	}
+
+	href, err = nav.InEmbedded("items").Follow("product").url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if href != ts.URL+"/products/7" {
+		t.Errorf("Expected the first item's product, got %s", href)
+	}
+
+	if hits["/orders/1"] != 2 || len(hits) != 1 {
+		t.Errorf("Expected only the order to be requested, got %v", hits)
+	}
+
+	_, err = nav.InEmbedded("missing").Follow("product").url()
+	if _, ok := err.(EmbeddedNotFoundError); !ok {
+		t.Errorf("Expected EmbeddedNotFoundError, got %v", err)
+	}
+}
+
+func TestFollowingAnIRI(t *testing.T) {
+	paths := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.RequestURI())
+		fmt.Fprint(w, `{ "_links": { "menu": { "href": "/menü/café?sort=größe" } } }`)
+	}))
+	defer ts.Close()
+
+	href, err := Navigator(ts.URL).Follow("menu").url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := ts.URL + "/men%C3%BC/caf%C3%A9?sort=gr%C3%B6%C3%9Fe"; href != expected {
+		t.Errorf("Expected url %s, got %s", expected, href)
+	}
+
+	if _, err := Navigator(ts.URL).Follow("menu").Get(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "/men%C3%BC/caf%C3%A9?sort=gr%C3%B6%C3%9Fe"; paths[len(paths)-1] != expected {
+		t.Errorf("Expected request for %s, got %s", expected, paths[len(paths)-1])
+	}
+}
+
+func TestInvalidRootURI(t *testing.T) {
+	for _, root := range []string{"", "/relative", "http://exa mple.com", "http://%zz"} {
+		_, err := Navigator(root).Follow("next").Get()
+		if invalid, ok := err.(InvalidUrlError); !ok || invalid.url != root {
+			t.Errorf("Expected InvalidUrlError for %q, got %v", root, err)
+		}
+
+		_, err = Navigator(root).Get()
+		if _, ok := err.(InvalidUrlError); !ok {
+			t.Errorf("Expected InvalidUrlError for %q without following, got %v", root, err)
+		}
+	}
+}
+
+func TestAllowedMethods(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "OPTIONS" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path == "/forbidden" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Add("Allow", "GET, HEAD,PUT")
+		w.Header().Add("Allow", "DELETE")
+	}))
+	defer ts.Close()
+
+	methods, err := Navigator(ts.URL).AllowedMethods()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "[GET HEAD PUT DELETE]"; fmt.Sprint(methods) != expected {
+		t.Errorf("Expected methods %s, got %v", expected, methods)
+	}
+
+	_, err = Navigator(ts.URL + "/forbidden").AllowedMethods()
+	if err == nil || err.Error() != "OPTIONS of "+ts.URL+"/forbidden failed: 403 Forbidden" {
+		t.Errorf("Expected an error for a 403, got %v", err)
+	}
+}
+
+func TestAllowedMethodsWithoutResponseRequest(t *testing.T) {
+	client := &MockHttpClient{}
+	client.Respond("http://api.example.com/", 200, `{ "_links": { "orders": { "href": "/orders" } } }`)
+	client.RespondFunc("http://api.example.com/orders", func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 405, Status: "405 Method Not Allowed", Body: http.NoBody}, nil
+	})
+
+	_, err := Navigator("http://api.example.com/").WithClient(client).Follow("orders").AllowedMethods()
+	if expected := "OPTIONS of http://api.example.com/ -> orders failed: 405 Method Not Allowed"; err == nil || err.Error() != expected {
+		t.Errorf("Expected %s, got %v", expected, err)
+	}
+}
+
+func TestWithHeaders(t *testing.T) {
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer ts.Close()
+
+	h := http.Header{"x-multi": {"1", "2"}, "X-Single": {"b"}}
+	nav := Navigator(ts.URL).
+		AddHeaders(map[string]string{"X-Single": "a", "X-Kept": "k"}).
+		WithHeaders(h)
+	h.Add("X-Multi", "3")
+
+	if _, err := nav.Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := received["X-Multi"]; fmt.Sprint(v) != "[1 2]" {
+		t.Errorf("Expected X-Multi to be [1 2], got %v", v)
+	}
+	if v := received.Get("X-Single"); v != "b" {
+		t.Errorf("Expected X-Single to be replaced with b, got %s", v)
+	}
+	if v := received.Get("X-Kept"); v != "k" {
+		t.Errorf("Expected X-Kept to be kept, got %s", v)
+	}
+}
+
+func TestFollowAny(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	href, err := Navigator(ts.URL).FollowAny("ea:child", "child", "next").url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if href != ts.URL+"/child" {
+		t.Errorf("Expected the first existing relation to be followed, got %s", href)
+	}
+
+	_, err = Navigator(ts.URL).FollowAny("ea:child", "kid").url()
+	if _, ok := err.(AnyLinkNotFoundError); !ok {
+		t.Fatalf("Expected AnyLinkNotFoundError, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "Response didn't contain any of [ea:child kid] link relations") {
+		t.Errorf("Expected the error to list every relation tried, got %v", err)
+	}
+}
+
+func TestNavigatorLinks(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	links, err := Navigator(ts.URL).Follow("profiled").Links()
+	if err == nil {
+		t.Errorf("Expected an error for a non-HAL resource, got %v", links)
+	}
+
+	links, err = Navigator(ts.URL).Follow("child").Links()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if href, _ := links.Href("parent"); href != "/" {
+		t.Errorf("Expected the child's parent link, got %s", href)
+	}
+}
+
+func TestUseLinkHeader(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Link", `</orders/2>; rel="next"`)
+		w.Header().Add("Link", `<items>; rel="item"`)
+		fmt.Fprint(w, `{ "_links": { "item": { "href": "/items/1" } } }`)
+	}))
+	defer ts.Close()
+
+	_, err := Navigator(ts.URL).Follow("next").url()
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Errorf("Expected Link header to be ignored by default, got %v", err)
+	}
+
+	nav := Navigator(ts.URL + "/orders/1").UseLinkHeader(true)
+
+	href, err := nav.Follow("next").url()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if href != ts.URL+"/orders/2" {
+		t.Errorf("Expected the next link from the header, got %s", href)
+	}
+
+	links, err := nav.Links()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set := links.Items["item"]; len(set) != 2 || set[0].Href != "/items/1" || set[1].Href != "items" {
+		t.Errorf("Expected body links before header links, got %v", set)
+	}
+}
+
+func TestNavigatorIsTemplated(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	nav := Navigator(ts.URL)
+
+	if templated, err := nav.IsTemplated("one"); err != nil || !templated {
+		t.Errorf("Expected one to be templated, got %v, %v", templated, err)
+	}
+
+	if templated, err := nav.IsTemplated("next"); err != nil || templated {
+		t.Errorf("Expected next not to be templated, got %v, %v", templated, err)
+	}
+
+	if _, err := nav.IsTemplated("missing"); err == nil {
+		t.Error("Expected an error for a missing relation")
+	}
+}
+
+func TestTrace(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	url, steps, err := Navigator(ts.URL).Follow("child").Follow("parent").Follow("relative").Trace()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if url != ts.URL+"/2nd" {
+		t.Errorf("Expected %s/2nd, got %s", ts.URL, url)
+	}
+
+	expected := []struct{ rel, url string }{
+		{"child", ts.URL},
+		{"parent", ts.URL + "/child"},
+		{"relative", ts.URL + "/"},
+	}
+	if len(steps) != len(expected) {
+		t.Fatalf("Expected %d steps, got %v", len(expected), steps)
+	}
+	for i, step := range steps {
+		if step.Rel != expected[i].rel || step.URL != expected[i].url {
+			t.Errorf("Expected step %d to be %s in %s, got %s in %s", i, expected[i].rel, expected[i].url, step.Rel, step.URL)
+		}
+	}
+	if !strings.Contains(string(steps[1].Body), `"parent"`) {
+		t.Errorf("Expected body of /child, got %s", steps[1].Body)
+	}
+
+	_, steps, err = Navigator(ts.URL).Follow("child").Follow("missing").Trace()
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Errorf("Expected LinkNotFoundError, got %v", err)
+	}
+	if len(steps) != 2 || steps[1].Rel != "missing" {
+		t.Errorf("Expected steps up to the missing relation, got %v", steps)
+	}
+}
+
+func TestCacheRelation(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	api := Navigator(ts.URL).CacheRelation("", time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := api.Follow("child").Follow("parent").url(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if hits["/"] != 1 {
+		t.Errorf("Expected the root to be fetched once, got %d", hits["/"])
+	}
+	if hits["/child"] != 2 {
+		t.Errorf("Expected the uncached child to be fetched every time, got %d", hits["/child"])
+	}
+
+	if _, err := api.CacheRelation("child", time.Minute).Follow("child").Links(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.CacheRelation("child", time.Minute).Follow("child").Follow("parent").url(); err != nil {
+		t.Fatal(err)
+	}
+	if hits["/child"] != 3 {
+		t.Errorf("Expected the cached child to be fetched once more, got %d", hits["/child"])
+	}
+
+	expiring := Navigator(ts.URL).CacheRelation("", time.Millisecond)
+	expiring.Follow("child").url()
+	time.Sleep(5 * time.Millisecond)
+	expiring.Follow("child").url()
+
+	if hits["/"] != 3 {
+		t.Errorf("Expected the root to be fetched again once expired, got %d", hits["/"])
+	}
+}
+
+func TestCacheRelationIsPerCredentials(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	api := Navigator(ts.URL).CacheRelation("", time.Minute)
+
+	for _, nav := range []navigator{api.WithBearerToken("alice"), api.WithBearerToken("bob"), api.WithBearerToken("alice")} {
+		if _, err := nav.Follow("child").url(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if hits["/"] != 2 {
+		t.Errorf("Expected the root to be fetched once for each token, got %d", hits["/"])
+	}
+}
+
+func TestCacheRelationOfFollowAny(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	api := Navigator(ts.URL).CacheRelation("child", time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := api.FollowAny("missing", "child").Follow("parent").url(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if hits["/child"] != 1 {
+		t.Errorf("Expected the child to be cached by the alternative followed, got %d fetches", hits["/child"])
+	}
+}
+
+func TestCacheRelationDoesNotKeepTraceBodies(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	api := Navigator(ts.URL).CacheRelation("", time.Minute)
+
+	_, steps, err := api.Follow("child").Trace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 1 || len(steps[0].Body) == 0 {
+		t.Fatalf("Expected the traced root body, got %v", steps)
+	}
+
+	doc, ok := api.documents.get(api.cacheKey(ts.URL))
+	if !ok {
+		t.Fatal("Expected the root to be cached")
+	}
+	if doc.body != nil {
+		t.Errorf("Expected the cached root to have no body, got %s", doc.body)
+	}
+}
+
+func TestWithClient(t *testing.T) {
+	client := &MockHttpClient{}
+	client.Respond("http://api.example.com/", 200, `{ "_links": { "orders": { "href": "/orders" } } }`)
+	client.Respond("http://api.example.com/orders", 200, `{}`)
+
+	base := Navigator("http://api.example.com/")
+
+	if _, err := base.WithClient(client).Follow("orders").Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	if requests := client.Requests(); len(requests) != 2 {
+		t.Errorf("Expected both requests to use the client, got %v", requests)
+	}
+
+	if base.HttpClient != http.DefaultClient {
+		t.Errorf("Expected the base navigator to keep its client, got %v", base.HttpClient)
+	}
+}
+
+func TestWarnUndefinedCuries(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{ "_links": {
+      "curies": [{ "name": "ea", "href": "http://example.com/docs/rels/{rel}", "templated": true }],
+      "ea:orders": { "href": "/orders" },
+      "acme:admin": { "href": "/admin" },
+      "http://example.com/rels/users": { "href": "/users" }
+    } }`)
+	}))
+	defer ts.Close()
+
+	warnings := []string{}
+	nav := Navigator(ts.URL).WarnUndefinedCuries(func(rel, url string) {
+		warnings = append(warnings, rel+" "+url)
+	})
+
+	for _, rel := range []string{"ea:orders", "acme:admin", "http://example.com/rels/users"} {
+		if _, err := nav.Follow(rel).url(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []string{"acme:admin " + ts.URL}
+	if fmt.Sprint(warnings) != fmt.Sprint(expected) {
+		t.Errorf("Expected warnings %q, got %q", expected, warnings)
+	}
+}
+
+func TestWithDecoder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{ "_links": { "self": { "href": "/" } }, "name": "Halgo", "stars": 10 }`)
+	}))
+	defer ts.Close()
+
+	decodes := 0
+	nav := Navigator(ts.URL).WithDecoder(func(r io.Reader, v interface{}) error {
+		decodes++
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		return dec.Decode(v)
+	})
+
+	var strict struct{ Name string }
+	if err := nav.Follow("self").Unmarshal(&strict); err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("Expected unknown field error, got %v", err)
+	}
+
+	if decodes != 2 {
+		t.Errorf("Expected the link document and the resource to be decoded, got %d decodes", decodes)
+	}
+
+	var all struct {
+		Links
+		Name  string
+		Stars int
+	}
+	if err := nav.Follow("self").Unmarshal(&all); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+// endlessReader is a response body which never ends, counting how much
+// of it was read.
+type endlessReader struct {
+	read int64
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'x'
+	}
+	r.read += int64(len(p))
+	return len(p), nil
+}
+
+func (r *endlessReader) Close() error {
+	return nil
+}
+
+func TestOversizedErrorBodyIsTruncated(t *testing.T) {
+	body := &endlessReader{}
+	client := &MockHttpClient{}
+	client.RespondFunc("http://api.example.com/", func(req *http.Request) (*http.Response, error) {
+		header := http.Header{"Content-Type": {"application/problem+json"}}
+		return &http.Response{StatusCode: 500, Status: "500 Internal Server Error", Header: header, Body: body, Request: req}, nil
+	})
+
+	nav := Navigator("http://api.example.com/").WithClient(client).CheckStatus(true)
+
+	_, err := nav.Get()
+
+	var httpErr HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Expected a HTTPError for a problem document which is too large, got %v", err)
+	}
+	if len(httpErr.Body) != maxSnippetLength+len("...") {
+		t.Errorf("Expected the body to be truncated, got %d bytes", len(httpErr.Body))
+	}
+	if body.read > maxErrorBodyLength {
+		t.Errorf("Expected at most %d bytes to be read, got %d", maxErrorBodyLength, body.read)
+	}
+
+	body.read = 0
+	nav.WithMaxResponseSize(100).Get()
+	if body.read > 100 {
+		t.Errorf("Expected at most the maximum response size to be read, got %d", body.read)
+	}
+}
+
+func TestDecoderIsUsedForEveryDocument(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{ "_links": { "self": { "href": "/" } }, "name": "Halgo" }`)
+	}))
+	defer ts.Close()
+
+	type project struct{ Name string }
+
+	decoded := []string{}
+	nav := Navigator(ts.URL).WithDecoder(func(r io.Reader, v interface{}) error {
+		decoded = append(decoded, fmt.Sprintf("%T", v))
+		return json.NewDecoder(r).Decode(v)
+	})
+
+	var p project
+	steps := map[string]func() error{
+		"Unmarshal": func() error { return nav.Unmarshal(&p) },
+		"UnmarshalResponse": func() error {
+			_, err := nav.UnmarshalResponse(&p)
+			return err
+		},
+		"GetResource": func() error {
+			_, err := nav.GetResource(&p)
+			return err
+		},
+		"Update": func() error {
+			_, err := nav.Update(&p, func(interface{}) error { return nil })
+			return err
+		},
+		"Fetch": func() error {
+			res, err := nav.Fetch()
+			if err != nil {
+				return err
+			}
+			return res.Decode(&p)
+		},
+	}
+
+	expected := map[string]string{
+		"Unmarshal":         "[*halgo.project]",
+		"UnmarshalResponse": "[*halgo.project]",
+		"GetResource":       "[*halgo.project *halgo.halDocument]",
+		"Update":            "[*halgo.project *halgo.halDocument]",
+		"Fetch":             "[*halgo.halDocument *halgo.project]",
+	}
+
+	for name, step := range steps {
+		decoded = decoded[:0]
+		if err := step(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if fmt.Sprint(decoded) != expected[name] {
+			t.Errorf("%s: Expected decodes %s, got %v", name, expected[name], decoded)
+		}
+	}
+}
+
+func TestWithMaxResponseSize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/large" {
+			fmt.Fprint(w, `{ "_links": { "self": { "href": "/large" } }, "padding": "`+strings.Repeat("x", 100)+`" }`)
+			return
+		}
+		fmt.Fprint(w, `{ "_links": { "large": { "href": "/large" } } }`)
+	}))
+	defer ts.Close()
+
+	nav := Navigator(ts.URL).WithMaxResponseSize(100)
+
+	var v struct{}
+	if err := Navigator(ts.URL).Follow("large").Unmarshal(&v); err != nil {
+		t.Errorf("Expected no limit by default, got %v", err)
+	}
+
+	err := nav.Follow("large").Unmarshal(&v)
+	if _, ok := err.(ResponseTooLargeError); !ok {
+		t.Errorf("Expected ResponseTooLargeError, got %v", err)
+	}
+
+	_, err = nav.Follow("large").Follow("self").url()
+	if !errors.As(err, new(ResponseTooLargeError)) {
+		t.Errorf("Expected ResponseTooLargeError for a link document, got %v", err)
+	}
+
+	if _, err := nav.Follow("large").url(); err != nil {
+		t.Errorf("Expected documents under the limit to be read, got %v", err)
+	}
+}
@@ -49,3 +49,83 @@ func TestHrefParams(t *testing.T) {
 		}
 	}
 }
+
+var level4Params = P{
+	"var":   "value",
+	"hello": "Hello World!",
+	"path":  "/foo/bar",
+	"list":  []interface{}{"red", "green", "blue"},
+	"keys":  map[string]interface{}{"semi": ";"},
+}
+
+// operatorTests expand every level 4 operator with each kind of value.
+var operatorTests = []struct {
+	name     string
+	expected string
+	url      string
+}{
+	{"simple scalar", "/x/value", "/x/{var}"},
+	{"simple prefix", "/x/val", "/x/{var:3}"},
+	{"simple list", "/x/red,green,blue", "/x/{list}"},
+	{"simple exploded list", "/x/red,green,blue", "/x/{list*}"},
+	{"simple map", "/x/semi,%3B", "/x/{keys}"},
+	{"simple exploded map", "/x/semi=%3B", "/x/{keys*}"},
+	{"reserved scalar", "/x/value", "/x/{+var}"},
+	{"reserved prefix", "/x/val", "/x/{+var:3}"},
+	{"reserved list", "/x/red,green,blue", "/x/{+list}"},
+	{"reserved exploded list", "/x/red,green,blue", "/x/{+list*}"},
+	{"reserved map", "/x/semi,;", "/x/{+keys}"},
+	{"reserved exploded map", "/x/semi=;", "/x/{+keys*}"},
+	{"fragment scalar", "/x#value", "/x{#var}"},
+	{"fragment prefix", "/x#val", "/x{#var:3}"},
+	{"fragment list", "/x#red,green,blue", "/x{#list}"},
+	{"fragment exploded list", "/x#red,green,blue", "/x{#list*}"},
+	{"fragment map", "/x#semi,;", "/x{#keys}"},
+	{"fragment exploded map", "/x#semi=;", "/x{#keys*}"},
+	{"label scalar", "/x.value", "/x{.var}"},
+	{"label prefix", "/x.val", "/x{.var:3}"},
+	{"label list", "/x.red,green,blue", "/x{.list}"},
+	{"label exploded list", "/x.red.green.blue", "/x{.list*}"},
+	{"label map", "/x.semi,%3B", "/x{.keys}"},
+	{"label exploded map", "/x.semi=%3B", "/x{.keys*}"},
+	{"path segment scalar", "/x/value", "/x{/var}"},
+	{"path segment prefix", "/x/val", "/x{/var:3}"},
+	{"path segment list", "/x/red,green,blue", "/x{/list}"},
+	{"path segment exploded list", "/x/red/green/blue", "/x{/list*}"},
+	{"path segment map", "/x/semi,%3B", "/x{/keys}"},
+	{"path segment exploded map", "/x/semi=%3B", "/x{/keys*}"},
+	{"path-style parameter scalar", "/x;var=value", "/x{;var}"},
+	{"path-style parameter prefix", "/x;var=val", "/x{;var:3}"},
+	{"path-style parameter list", "/x;list=red,green,blue", "/x{;list}"},
+	{"path-style parameter exploded list", "/x;list=red;list=green;list=blue", "/x{;list*}"},
+	{"path-style parameter map", "/x;keys=semi,%3B", "/x{;keys}"},
+	{"path-style parameter exploded map", "/x;semi=%3B", "/x{;keys*}"},
+	{"form-style query scalar", "/x?var=value", "/x{?var}"},
+	{"form-style query prefix", "/x?var=val", "/x{?var:3}"},
+	{"form-style query list", "/x?list=red,green,blue", "/x{?list}"},
+	{"form-style query exploded list", "/x?list=red&list=green&list=blue", "/x{?list*}"},
+	{"form-style query map", "/x?keys=semi,%3B", "/x{?keys}"},
+	{"form-style query exploded map", "/x?semi=%3B", "/x{?keys*}"},
+	{"form-style query continuation scalar", "/x?fixed=yes&var=value", "/x?fixed=yes{&var}"},
+	{"form-style query continuation prefix", "/x?fixed=yes&var=val", "/x?fixed=yes{&var:3}"},
+	{"form-style query continuation list", "/x?fixed=yes&list=red,green,blue", "/x?fixed=yes{&list}"},
+	{"form-style query continuation exploded list", "/x?fixed=yes&list=red&list=green&list=blue", "/x?fixed=yes{&list*}"},
+	{"form-style query continuation map", "/x?fixed=yes&keys=semi,%3B", "/x?fixed=yes{&keys}"},
+	{"form-style query continuation exploded map", "/x?fixed=yes&semi=%3B", "/x?fixed=yes{&keys*}"},
+	{"reserved escaping", "/Hello%20World!", "/{+hello}"},
+	{"reserved path", "/foo/bar/here", "{+path}/here"},
+	{"path segment escaping", "/files/%2Ffoo%2Fbar", "/files{/path}"},
+}
+
+func TestHrefParamsOperators(t *testing.T) {
+	for _, test := range operatorTests {
+		links := Links{}.Link(test.name, test.url)
+		href, err := links.HrefParams(test.name, level4Params)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if href != test.expected {
+			t.Errorf("%s: Expected href to be '%s', got '%s'", test.name, test.expected, href)
+		}
+	}
+}
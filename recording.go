@@ -0,0 +1,198 @@
+package halgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// RecordingMode is whether a RecordingHttpClient records or replays. The
+// zero value is Replay, so a client never touches the network or the
+// cassette unless it's asked to record.
+type RecordingMode int
+
+const (
+	// Replay serves responses from the cassette without making requests.
+	Replay RecordingMode = iota
+
+	// Record makes real requests and saves them to the cassette.
+	Record
+)
+
+// RecordingHttpClient is a HttpClient decorator for integration tests
+// against real APIs. In Record mode it makes requests with the wrapped
+// HttpClient and saves each request and its response to the Cassette
+// file. In Replay mode it serves the responses saved in the Cassette
+// instead, without touching the network. Replay is the default.
+//
+//     client := &halgo.RecordingHttpClient{
+//       HttpClient: http.DefaultClient,
+//       Cassette:   "testdata/orders.json",
+//       Mode:       halgo.Record,
+//     }
+//
+// Requests are matched to recordings by method and URL, and by the
+// values of any headers in MatchHeaders. Repeated requests are replayed
+// in the order they were recorded.
+//
+// Credentials in the Authorization, Cookie and Proxy-Authorization
+// headers of requests are never saved, so cassettes can be committed.
+type RecordingHttpClient struct {
+	HttpClient
+	Cassette     string
+	Mode         RecordingMode
+	MatchHeaders []string
+
+	mu           sync.Mutex
+	loaded       bool
+	interactions []Interaction
+	replayed     map[int]bool
+}
+
+// Interaction is a request and its response, as saved in a cassette.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedResponse is a response saved in a cassette.
+type RecordedResponse struct {
+	Status     string      `json:"status"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+func (c *RecordingHttpClient) Do(req *http.Request) (*http.Response, error) {
+	if c.Mode == Record {
+		return c.record(req)
+	}
+
+	return c.replay(req)
+}
+
+// record makes req and saves it, along with its response, to the
+// cassette.
+func (c *RecordingHttpClient) record(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	res, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, Interaction{
+		RecordedRequest{req.Method, req.URL.String(), recordedHeader(req.Header), reqBody},
+		RecordedResponse{res.Status, res.StatusCode, cloneHeader(res.Header), body},
+	})
+
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(c.Cassette, data, 0644); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// unrecordedHeaders are the request headers left out of cassettes, as they
+// hold credentials.
+var unrecordedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// recordedHeader returns a copy of the headers of a request to save in a
+// cassette, without any credentials.
+func recordedHeader(h http.Header) http.Header {
+	header := cloneHeader(h)
+	for _, name := range unrecordedHeaders {
+		header.Del(name)
+	}
+	return header
+}
+
+// replay responds to req with the first matching recording which hasn't
+// been replayed yet, or the last matching recording if they all have.
+func (c *RecordingHttpClient) replay(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.loaded {
+		data, err := ioutil.ReadFile(c.Cassette)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &c.interactions); err != nil {
+			return nil, fmt.Errorf("Unable to read cassette %s: %v", c.Cassette, err)
+		}
+		c.loaded = true
+		c.replayed = map[int]bool{}
+	}
+
+	match := -1
+	for i, interaction := range c.interactions {
+		if !c.matches(interaction.Request, req) {
+			continue
+		}
+
+		match = i
+		if !c.replayed[i] {
+			break
+		}
+	}
+
+	if match < 0 {
+		return nil, fmt.Errorf("No recording in %s for %s %s", c.Cassette, req.Method, req.URL)
+	}
+	c.replayed[match] = true
+
+	recorded := c.interactions[match].Response
+	return &http.Response{
+		Status:        recorded.Status,
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        cloneHeader(recorded.Header),
+		Body:          ioutil.NopCloser(bytes.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// matches returns whether req is the same as a recorded request.
+func (c *RecordingHttpClient) matches(recorded RecordedRequest, req *http.Request) bool {
+	if recorded.Method != req.Method || recorded.URL != req.URL.String() {
+		return false
+	}
+
+	for _, name := range c.MatchHeaders {
+		if recorded.Header.Get(name) != req.Header.Get(name) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,117 @@
+package halgo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordingHttpClientReplaysRecordedSession(t *testing.T) {
+	dir, err := ioutil.TempDir("", "halgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cassette := filepath.Join(dir, "cassette.json")
+
+	count := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.Header().Set("X-Count", fmt.Sprint(count))
+		fmt.Fprintf(w, `{ "_links": { "self": { "href": "/" } }, "count": %d }`, count)
+	}))
+
+	navigate := func(client HttpClient) []int {
+		nav := Navigator(ts.URL)
+		nav.HttpClient = client
+
+		counts := []int{}
+		for i := 0; i < 3; i++ {
+			var v struct{ Count int }
+			if err := nav.Follow("self").Unmarshal(&v); err != nil {
+				t.Fatal(err)
+			}
+			counts = append(counts, v.Count)
+		}
+		return counts
+	}
+
+	recorded := navigate(&RecordingHttpClient{HttpClient: http.DefaultClient, Cassette: cassette, Mode: Record})
+	ts.Close()
+
+	replayed := navigate(&RecordingHttpClient{Cassette: cassette})
+
+	if fmt.Sprint(replayed) != fmt.Sprint(recorded) {
+		t.Errorf("Expected replay %v to match recording %v", replayed, recorded)
+	}
+}
+
+func TestRecordingHttpClientMatchesHeaders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "halgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cassette := filepath.Join(dir, "cassette.json")
+
+	data := `[{
+    "request": { "method": "GET", "url": "http://example.com/", "header": { "Accept-Language": ["de"] } },
+    "response": { "status": "200 OK", "statusCode": 200, "header": {}, "body": "aGFsbG8=" }
+  }]`
+	if err := ioutil.WriteFile(cassette, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &RecordingHttpClient{Cassette: cassette, Mode: Replay, MatchHeaders: []string{"Accept-Language"}}
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	req.Header.Set("Accept-Language", "de")
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := ioutil.ReadAll(res.Body); string(body) != "hallo" {
+		t.Errorf("Expected recorded body hallo, got %s", body)
+	}
+
+	req.Header.Set("Accept-Language", "en")
+	if _, err := client.Do(req); err == nil {
+		t.Error("Expected no recording to match a different header")
+	}
+}
+
+func TestRecordingHttpClientDoesNotSaveCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "halgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cassette := filepath.Join(dir, "cassette.json")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	client := &RecordingHttpClient{HttpClient: http.DefaultClient, Cassette: cassette, Mode: Record}
+	nav := Navigator(ts.URL).WithClient(client).
+		WithBearerToken("secret").
+		AddHeaders(map[string]string{"Cookie": "session=secret", "Proxy-Authorization": "Basic secret"})
+	if _, err := nav.Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("Expected no credentials in the cassette, got %s", data)
+	}
+}
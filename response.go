@@ -0,0 +1,152 @@
+package halgo
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// Response is a fetched resource. It bundles the HTTP response with the
+// links of the resource, and can continue navigating from the resource
+// without fetching it again.
+//
+//     res, err := Navigator("http://api.example.com").
+//       Follow("products").
+//       Fetch()
+//
+//     var products Products
+//     err = res.Decode(&products)
+//
+//     next, err := res.Follow("next").Fetch()
+type Response struct {
+	*http.Response
+
+	// Links are the links of the resource.
+	Links Links
+
+	body []byte
+	nav  navigator
+}
+
+// Fetch performs a GET request on the tip of the follow queue and returns
+// the resource as a Response. The response body is read and closed, but
+// remains readable from Body.
+//
+// See GET for a note on how the navigator executes requests.
+func (n navigator) Fetch() (*Response, error) {
+	nav, cancel := n.begin()
+	defer cancel()
+
+	url, err := nav.url()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newHalRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := nav.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := nav.readBody(res, url)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var doc halDocument
+	if err := nav.parseDocument(res, url, body, &doc); err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		Response: res,
+		Links:    doc.Links,
+		body:     body,
+		nav:      n.at(effectiveURL(res, url), res.Header.Get("Content-Type"), doc),
+	}, nil
+}
+
+// NavFromResponse creates a navigator positioned at the document in an
+// already fetched response, so following relations starts from the links
+// in the response without fetching it again. uri is the URI of the
+// document, which relative links are resolved against; if it's empty the
+// URL of the response's request is used.
+//
+// The body of resp is read but remains readable afterwards. An error is
+// returned if the body has already been closed.
+//
+//     res, err := http.Get("http://api.example.com/products")
+//     nav, err := halgo.NavFromResponse(res, "")
+//     nav.Follow("next").Get()
+func NavFromResponse(resp *http.Response, uri string) (navigator, error) {
+	return Navigator(uri).FromResponse(resp, uri)
+}
+
+// FromResponse is like NavFromResponse, but the navigator it creates has
+// the options of n, and the body is read like any other document n
+// fetches, so a maximum set with WithMaxResponseSize applies to it.
+//
+//     nav, err := halgo.Navigator("").
+//       WithMaxResponseSize(1 << 20).
+//       FromResponse(res, "")
+func (n navigator) FromResponse(resp *http.Response, uri string) (navigator, error) {
+	body, err := n.readLimited(resp, uri)
+	resp.Body.Close()
+	if _, ok := err.(ResponseTooLargeError); ok {
+		return navigator{}, err
+	}
+	if err != nil {
+		return navigator{}, fmt.Errorf("Unable to read response body, it may already be closed: %v", err)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if uri == "" {
+		uri = effectiveURL(resp, "")
+	}
+	if uri == "" {
+		return navigator{}, InvalidUrlError{uri}
+	}
+
+	var doc halDocument
+	if err := n.parseDocument(resp, uri, body, &doc); err != nil {
+		return navigator{}, err
+	}
+
+	return n.at(uri, resp.Header.Get("Content-Type"), doc), nil
+}
+
+// at returns a navigator positioned at the document at uri, which has
+// already been fetched and has the given content type.
+func (n navigator) at(uri, contentType string, doc halDocument) navigator {
+	n.rootUri = uri
+	n.relocated = true
+	n.path = []relation{}
+	n.seed = linkCache{uri: document{uri, contentType, doc.Links, doc.Embedded, nil}}
+	return n
+}
+
+// Decode unmarshals the body of the response into v, with the Decoder of
+// the navigator which fetched it.
+func (r *Response) Decode(v interface{}) error {
+	return r.nav.decode(r.body, v)
+}
+
+// Follow creates a navigator which starts at this resource and follows
+// rel, without fetching this resource again.
+func (r *Response) Follow(rel string) navigator {
+	return r.nav.Follow(rel)
+}
+
+// Followf creates a navigator which starts at this resource and follows
+// rel with a set of parameters to expand on execution, without fetching
+// this resource again.
+func (r *Response) Followf(rel string, params P) navigator {
+	return r.nav.Followf(rel, params)
+}
@@ -0,0 +1,156 @@
+package halgo
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFetchAndFollowFromResponse(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	res, err := Navigator(ts.URL).Follow("pages").Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var page struct{ Page int }
+	if err := res.Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Page != 1 {
+		t.Errorf("Expected page to be 1, got %d", page.Page)
+	}
+
+	if href, _ := res.Links.Href("next"); href != "/pages/2" {
+		t.Errorf("Expected next link to be /pages/2, got %s", href)
+	}
+
+	next, err := res.Follow("next").Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next.Request.URL.String() != ts.URL+"/pages/2" {
+		t.Errorf("Expected url to be %s, got %s", ts.URL+"/pages/2", next.Request.URL)
+	}
+
+	if hits["/pages/1"] != 1 {
+		t.Errorf("Expected 1 request to /pages/1, got %d", hits["/pages/1"])
+	}
+
+	if hits["/"] != 1 {
+		t.Errorf("Expected 1 request to /, got %d", hits["/"])
+	}
+}
+
+func TestFetchWithoutResponseRequest(t *testing.T) {
+	client := &MockHttpClient{}
+	client.RespondFunc("http://api.example.com/", func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(`{ "_links": { "next": { "href": "/2" } } }`)),
+		}, nil
+	})
+	client.RespondFunc("http://api.example.com/broken", func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader("[" + strings.Repeat("x", 1000))),
+		}, nil
+	})
+
+	decodes := 0
+	nav := Navigator("http://api.example.com/").
+		WithClient(client).
+		WithDecoder(func(r io.Reader, v interface{}) error {
+			decodes++
+			return json.NewDecoder(r).Decode(v)
+		})
+
+	res, err := nav.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if href, err := res.Follow("next").url(); href != "http://api.example.com/2" {
+		t.Errorf("Expected next to resolve against the requested URL, got %s (%v)", href, err)
+	}
+
+	if decodes != 1 {
+		t.Errorf("Expected Fetch to use the navigator's decoder, got %d decodes", decodes)
+	}
+
+	_, err = Navigator("http://api.example.com/broken").WithClient(client).Fetch()
+	if err == nil || len(err.Error()) > 2*maxSnippetLength {
+		t.Errorf("Expected an error with a truncated body, got %v", err)
+	}
+}
+
+func TestNavFromResponse(t *testing.T) {
+	ts, hits := createTestHttpServer()
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/pages/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nav, err := NavFromResponse(res, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := nav.Follow("next").Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next.Request.URL.String() != ts.URL+"/pages/2" {
+		t.Errorf("Expected url to be %s, got %s", ts.URL+"/pages/2", next.Request.URL)
+	}
+
+	if hits["/pages/1"] != 1 {
+		t.Errorf("Expected 1 request to /pages/1, got %d", hits["/pages/1"])
+	}
+
+	if body, _ := ioutil.ReadAll(res.Body); len(body) == 0 {
+		t.Error("Expected response body to still be readable")
+	}
+}
+
+func TestNavFromClosedResponse(t *testing.T) {
+	ts, _ := createTestHttpServer()
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/pages/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	_, err = NavFromResponse(res, "")
+	if err == nil || !strings.HasPrefix(err.Error(), "Unable to read response body") {
+		t.Errorf("Expected a closed body error, got %v", err)
+	}
+}
+
+func TestFromResponseWithMaxResponseSize(t *testing.T) {
+	body := &endlessReader{}
+	req, _ := http.NewRequest("GET", "http://api.example.com/", nil)
+	res := &http.Response{StatusCode: 200, Header: http.Header{}, Body: body, Request: req}
+
+	_, err := Navigator("").WithMaxResponseSize(100).FromResponse(res, "")
+	if _, ok := err.(ResponseTooLargeError); !ok {
+		t.Errorf("Expected ResponseTooLargeError, got %v", err)
+	}
+
+	if body.read > 101 {
+		t.Errorf("Expected at most 101 bytes to be read, got %d", body.read)
+	}
+}